@@ -2,43 +2,92 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	documentai "cloud.google.com/go/documentai/apiv1"
 	"cloud.google.com/go/firestore"
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
-	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/stellar/torifune/backend/internal/audit"
+	"github.com/stellar/torifune/backend/internal/billing"
 	"github.com/stellar/torifune/backend/internal/config"
+	"github.com/stellar/torifune/backend/internal/entitlements"
 	"github.com/stellar/torifune/backend/internal/handler"
+	"github.com/stellar/torifune/backend/internal/logging"
 	"github.com/stellar/torifune/backend/internal/middleware"
+	"github.com/stellar/torifune/backend/internal/ocr"
 	"github.com/stellar/torifune/backend/internal/repository"
 	"github.com/stellar/torifune/backend/internal/service/auth"
+	"github.com/stellar/torifune/backend/internal/usage"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	idleTimeout       = 120 * time.Second
+	readyCheckTimeout = 2 * time.Second
 )
 
 func main() {
-	// Load .env file (ignore error if not found, e.g., in production)
-	_ = godotenv.Load()
+	logger := logging.New()
 
-	// Load configuration
+	// Load configuration. Load resolves a .env file itself (see
+	// config.DotEnvSource), so there's no separate godotenv.Load call here.
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("failed to load configuration: %v", err)
+		logger.Error("failed to load configuration", "error", err)
+		os.Exit(1)
 	}
 
 	// Initialize Firestore client
 	ctx := context.Background()
 	firestoreClient, err := firestore.NewClient(ctx, cfg.GoogleProjectID)
 	if err != nil {
-		log.Fatalf("failed to create Firestore client: %v", err)
+		logger.Error("failed to create Firestore client", "error", err)
+		os.Exit(1)
 	}
+	// Closed after the server has finished draining in-flight requests, not
+	// merely at process exit, since it's deferred here and shutdown blocks.
 	defer firestoreClient.Close()
 
 	// Initialize repositories
 	userRepo := repository.NewFirestoreUserRepository(firestoreClient)
 	usageRepo := repository.NewFirestoreUsageRepository(firestoreClient, cfg.FreeTierLimit)
+	schemaRepo := repository.NewFirestoreSchemaRepository(firestoreClient)
+
+	// Initialize the auditor. Deployments can opt out entirely via
+	// AUDIT_LOGGING_ENABLED, in which case events are discarded.
+	var auditor audit.Auditor = audit.NoopAuditor{}
+	var auditLister audit.Lister
+	if cfg.AuditLoggingEnabled {
+		firestoreAuditor := audit.NewFirestoreAuditor(firestoreClient)
+		auditor = firestoreAuditor
+		auditLister = firestoreAuditor
+	}
+
+	// Initialize entitlements service, loading the signed license (if any) and
+	// keeping it fresh without requiring a restart.
+	entitlementsSvc, err := newEntitlementsService(ctx, cfg, firestoreClient)
+	if err != nil {
+		logger.Error("failed to initialize entitlements service", "error", err)
+		os.Exit(1)
+	}
+	if entitlementsSvc != nil {
+		if err := entitlementsSvc.Load(ctx); err != nil {
+			logger.Warn("entitlements: initial load failed, starting with no grants", "error", err)
+		}
+		resyncInterval := time.Duration(cfg.LicenseResyncIntervalSec) * time.Second
+		entitlementsSvc.StartResync(ctx, resyncInterval)
+	}
 
 	// Initialize OAuth providers
 	providers := make(map[auth.ProviderType]auth.OAuthProvider)
@@ -46,13 +95,20 @@ func main() {
 	// OAuth redirect URL base (backend callback endpoint)
 	redirectURLBase := cfg.FrontendURL + "/api/v1/auth/callback"
 
+	emailPolicy := auth.RequireVerifiedEmail
+	if !cfg.OIDCRequireVerifiedEmail {
+		emailPolicy = auth.AllowUnverifiedEmail
+	}
+
 	if cfg.HasGoogleOAuth() {
 		providers[auth.ProviderGoogle] = auth.NewGoogleProvider(
 			cfg.GoogleClientID,
 			cfg.GoogleClientSecret,
 			redirectURLBase+"/google",
+			emailPolicy,
+			cfg.GoogleDeviceAuthEndpoint,
 		)
-		log.Println("Google OAuth provider initialized")
+		logger.Info("Google OAuth provider initialized")
 	}
 
 	if cfg.HasMicrosoftOAuth() {
@@ -61,8 +117,10 @@ func main() {
 			cfg.MicrosoftClientSecret,
 			cfg.MicrosoftTenantID,
 			redirectURLBase+"/microsoft",
+			emailPolicy,
+			cfg.MicrosoftDeviceAuthEndpoint,
 		)
-		log.Println("Microsoft OAuth provider initialized")
+		logger.Info("Microsoft OAuth provider initialized")
 	}
 
 	if cfg.HasSlackOAuth() {
@@ -70,17 +128,108 @@ func main() {
 			cfg.SlackClientID,
 			cfg.SlackClientSecret,
 			redirectURLBase+"/slack",
+			cfg.SlackBotScopes,
 		)
-		log.Println("Slack OAuth provider initialized")
+		logger.Info("Slack OAuth provider initialized")
 	}
 
-	// Initialize JWT service
-	jwtService := auth.NewJWTService(cfg.JWTSecret, "torifune")
+	// Initialize the OAuth token store and refresher, if configured. These
+	// let long-lived integrations (e.g. the Slack events handler) keep using
+	// a provider's API well past the access token's original expiry.
+	tokenStore, err := newOAuthTokenStore(ctx, cfg, firestoreClient)
+	if err != nil {
+		logger.Error("failed to initialize oauth token store", "error", err)
+		os.Exit(1)
+	}
+	var tokenRefresher *auth.TokenRefresher
+	if tokenStore != nil {
+		tokenRefresher = auth.NewTokenRefresher(tokenStore, providers, 0)
+	}
+
+	// Initialize the rate limit store. Redis is required for horizontally
+	// scaled deployments (e.g. multiple Cloud Run instances), since the
+	// in-memory store only tracks requests seen by that one instance.
+	rateLimitStore, redisClient, err := newRateLimitStore(cfg)
+	if err != nil {
+		logger.Error("failed to initialize rate limit store", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize the token revocation store, sharing the rate limiter's
+	// Redis client (if any) so revoked tokens (see AuthHandler.Logout/Revoke)
+	// are recognized across every instance rather than just the one that
+	// revoked them.
+	revocationStore := newRevocationStore(redisClient)
+
+	// Initialize the refresh-token store, sharing the same Redis client, so
+	// a refresh token stolen and replayed across instances is still caught
+	// (see auth.JWTService.RefreshTokens).
+	refreshTokenStore := newRefreshTokenStore(redisClient)
+
+	jwtKeySet, err := newJWTKeySet(cfg)
+	if err != nil {
+		logger.Error("failed to initialize JWT signing keys", "error", err)
+		os.Exit(1)
+	}
+	jwtService := auth.NewJWTService(jwtKeySet, "torifune", revocationStore, refreshTokenStore)
+	jwksHandler := auth.NewJWKSHandler(jwtKeySet)
+
+	// Watch for configuration changes (e.g. a GCP Secret Manager secret
+	// getting a new version) and reload the JWT signing keys in place,
+	// without requiring a restart.
+	config.Watch(ctx, time.Duration(cfg.ConfigWatchIntervalSec)*time.Second, cfg, func(newCfg *config.Config) {
+		keySet, err := newJWTKeySet(newCfg)
+		if err != nil {
+			logger.Error("failed to reload JWT signing keys", "error", err)
+			return
+		}
+		jwtService.SetKeySet(keySet)
+		jwksHandler.SetKeySet(keySet)
+		logger.Info("reloaded JWT signing keys")
+	})
+
+	// Initialize the OCR processor and, if configured, the async job queue
+	// used for batches too large to process inline within a request.
+	documentAIClient, err := documentai.NewDocumentProcessorClient(ctx)
+	if err != nil {
+		logger.Error("failed to create Document AI client", "error", err)
+		os.Exit(1)
+	}
+	defer documentAIClient.Close()
+
+	processor := ocr.NewDocumentAIProcessor(documentAIClient, cfg.GoogleProjectID, cfg.GoogleLocation, cfg.DocumentAIFormParserID, cfg.DocumentAIProcessorID, schemaRepo)
+
+	asyncConfig, err := newOCRAsyncConfig(ctx, cfg, firestoreClient)
+	if err != nil {
+		logger.Error("failed to initialize async OCR batching", "error", err)
+		os.Exit(1)
+	}
+
+	// policyEngine supersedes FreeTierLimit/entitlementsSvc for quota
+	// enforcement, adding per-user daily and per-org concurrent-job limits and
+	// billing pro/enterprise overage to Stripe instead of rejecting it.
+	var billingReporter usage.BillingReporter
+	if cfg.StripeSecretKey != "" {
+		billingReporter = billing.NewStripeReporter(cfg.StripeSecretKey)
+	}
+	policyEngine := usage.NewPolicyEngine(usageRepo, asyncConfig.JobStore, entitlementsSvc, billingReporter)
+
+	// corsOrigins also doubles as the allow-list AuthHandler validates a
+	// client-supplied redirect_after against, so an open redirect can't be
+	// used to exfiltrate freshly-issued tokens to an arbitrary origin.
+	corsOrigins := cfg.CORSAllowedOrigins
+	if len(corsOrigins) == 0 {
+		corsOrigins = []string{cfg.FrontendURL}
+	}
 
 	// Initialize handlers
-	authHandler := handler.NewAuthHandler(providers, jwtService, userRepo)
-	ocrHandler := handler.NewOCRHandler(usageRepo, cfg.FreeTierLimit)
-	usageHandler := handler.NewUsageHandler(usageRepo)
+	authStateCodec := auth.NewAuthStateCodec(cfg.JWTSecret)
+	authHandler := handler.NewAuthHandler(providers, jwtService, userRepo, auditor, tokenStore, authStateCodec, cfg.FrontendURL, corsOrigins, cfg.PKCERequiredProviders)
+	ocrHandler := handler.NewOCRHandler(usageRepo, userRepo, cfg.FreeTierLimit, entitlementsSvc, auditor, processor, asyncConfig, policyEngine)
+	usageHandler := handler.NewUsageHandler(usageRepo, entitlementsSvc)
+	auditHandler := handler.NewAuditHandler(auditLister, entitlementsSvc)
+	schemaHandler := handler.NewSchemaHandler(schemaRepo, userRepo)
+	slackEventsHandler := handler.NewSlackEventsHandler(cfg.SlackSigningSecret, userRepo, usageRepo, cfg.FreeTierLimit, entitlementsSvc, processor, auditor, tokenRefresher)
 
 	// Initialize router
 	r := chi.NewRouter()
@@ -90,43 +239,71 @@ func main() {
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.RequestID)
 
-	// CORS middleware
+	// CORS middleware. CORSAllowedOrigins lets ops add staging/preview
+	// origins via env var; it falls back to FrontendURL alone when unset.
 	r.Use(middleware.CORS(middleware.CORSConfig{
-		AllowedOrigin: cfg.FrontendURL,
-	}))
-
-	// Rate limiting middleware
-	r.Use(middleware.RateLimit(middleware.RateLimitConfig{
-		RequestsPerMinute: 100,
+		AllowedOrigins:      corsOrigins,
+		AllowPrivateNetwork: cfg.CORSAllowPrivateNetwork,
 	}))
 
-	// JWT auth middleware with skip paths for auth endpoints and health check
+	// JWT auth middleware with skip paths for auth endpoints and health check.
+	// This runs before rate limiting so the limiter can key authenticated
+	// requests by user ID instead of falling back to IP for everything.
 	r.Use(middleware.Auth(middleware.AuthConfig{
-		JWTSecret: cfg.JWTSecret,
+		JWTService:   jwtService,
+		ExtraIssuers: extraIssuersFromConfig(cfg.ExtraJWTIssuers),
 		SkipPaths: []string{
 			"/health",
+			"/ready",
+			"/.well-known/jwks.json",
 			"/api/v1/auth/refresh",
 			"/api/v1/auth/logout",
+			"/api/v1/auth/revoke",
+			"/api/v1/slack/events",
 		},
 		SkipPrefixes: []string{
 			"/api/v1/auth/login/",
 			"/api/v1/auth/callback/",
+			"/api/v1/auth/device/",
+		},
+		Auditor: auditor,
+	}))
+
+	// Rate limiting middleware
+	r.Use(middleware.RateLimit(middleware.RateLimitConfig{
+		RequestsPerMinute: cfg.RateLimitPerMinute,
+		RouteLimits: []middleware.RouteLimit{
+			{Prefix: "/api/v1/ocr", RequestsPerMinute: cfg.RateLimitOCRPerMinute},
 		},
+		Store:          rateLimitStore,
+		TrustedProxies: cfg.TrustedProxies,
 	}))
 
-	// Health check endpoint
+	// Health check endpoint. Unlike /ready, this never depends on downstream
+	// services, so it stays up whenever the process is alive.
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
+	// Readiness endpoint: only returns 200 once Firestore (and Redis, if
+	// configured) are reachable, so rolling deploys don't route traffic to an
+	// instance that can't actually serve it yet.
+	r.Get("/ready", readinessHandler(firestoreClient, redisClient))
+
 	// Register auth routes (login, callback, refresh, logout)
 	authHandler.RegisterRoutes(r)
 
+	// Serve this service's own public signing keys, so other services can
+	// validate its tokens without sharing a secret (see auth.JWKSHandler).
+	r.Get("/.well-known/jwks.json", jwksHandler.ServeHTTP)
+
 	// Register OCR routes
 	r.Route("/api/v1/ocr", func(r chi.Router) {
 		r.Post("/process", ocrHandler.Process)
 		r.Post("/batch", ocrHandler.BatchProcess)
+		r.Get("/jobs/{id}", ocrHandler.GetJob)
+		r.Get("/jobs/{id}/stream", ocrHandler.StreamJob)
 	})
 
 	// Register usage routes
@@ -135,17 +312,252 @@ func main() {
 		r.Get("/history", usageHandler.GetHistory)
 	})
 
+	// Register audit routes (admin-only, gated by the audit_read entitlement)
+	r.Route("/api/v1/audit", func(r chi.Router) {
+		r.Get("/", auditHandler.List)
+	})
+
+	// Register extraction schema CRUD routes
+	schemaHandler.RegisterRoutes(r)
+
+	// Register the Slack Events API webhook (authenticated via signed
+	// requests, not JWT; see the SkipPaths entry above).
+	slackEventsHandler.RegisterRoutes(r)
+
 	// Determine port
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	log.Printf("Project ID: %s", cfg.GoogleProjectID)
-	log.Printf("Frontend URL: %s", cfg.FrontendURL)
+	srv := &http.Server{
+		Addr:              ":" + port,
+		Handler:           r,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	logger.Info("server starting", "port", port, "project_id", cfg.GoogleProjectID, "frontend_url", cfg.FrontendURL)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+			return
+		}
+		serverErr <- nil
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			logger.Error("server failed to start", "error", err)
+			os.Exit(1)
+		}
+	case sig := <-stop:
+		logger.Info("shutdown signal received, draining in-flight requests", "signal", sig.String())
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSec)*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("graceful shutdown did not complete cleanly", "error", err)
+		} else {
+			logger.Info("server shut down gracefully")
+		}
+	}
+}
+
+// readinessHandler reports whether the service's dependencies are reachable.
+// redisClient may be nil when the rate limiter is using the in-memory Store.
+func readinessHandler(firestoreClient *firestore.Client, redisClient *redis.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+		defer cancel()
+
+		logger := logging.FromContext(ctx)
+
+		if _, err := firestoreClient.Collection("_readiness").Limit(1).Documents(ctx).GetAll(); err != nil {
+			logger.Error("readiness check failed", "dependency", "firestore", "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatalf("server failed to start: %v", err)
+		if redisClient != nil {
+			if err := redisClient.Ping(ctx).Err(); err != nil {
+				logger.Error("readiness check failed", "dependency", "redis", "error", err)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// newEntitlementsService builds the entitlements service from configuration.
+// It returns a nil service (not an error) when no license source is configured,
+// so deployments without licensing simply fall back to FreeTierLimit.
+func newEntitlementsService(ctx context.Context, cfg *config.Config, firestoreClient *firestore.Client) (*entitlements.Service, error) {
+	if cfg.LicensePublicKeyPath == "" {
+		return nil, nil
+	}
+
+	keyPEM, err := os.ReadFile(cfg.LicensePublicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := entitlements.ParsePublicKeyPEM(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	var source entitlements.Source
+	switch {
+	case cfg.LicenseFirestoreEnabled:
+		source = entitlements.FirestoreSource{Client: firestoreClient}
+	case cfg.LicenseFilePath != "":
+		source = entitlements.FileSource{Path: cfg.LicenseFilePath}
+	default:
+		return nil, nil
+	}
+
+	return entitlements.NewService(source, publicKey), nil
+}
+
+// extraIssuersFromConfig maps config.ExtraJWTIssuer entries (parsed from
+// EXTRA_JWT_ISSUERS) into middleware.ExtraIssuer. Kept as a plain mapping
+// function, rather than config importing middleware, since config is a
+// leaf package.
+func extraIssuersFromConfig(issuers []config.ExtraJWTIssuer) []middleware.ExtraIssuer {
+	if len(issuers) == 0 {
+		return nil
+	}
+
+	result := make([]middleware.ExtraIssuer, 0, len(issuers))
+	for _, iss := range issuers {
+		result = append(result, middleware.ExtraIssuer{
+			Issuer:      iss.Issuer,
+			JWKSURL:     iss.JWKSURL,
+			Audience:    iss.Audience,
+			UserIDClaim: iss.UserIDClaim,
+		})
+	}
+	return result
+}
+
+// newOAuthTokenStore builds the auth.TokenStore used to persist and refresh
+// OAuth tokens. It returns a nil store (not an error) when no KMS key is
+// configured, so deployments without it simply never persist tokens past
+// the request that obtained them.
+func newOAuthTokenStore(ctx context.Context, cfg *config.Config, firestoreClient *firestore.Client) (auth.TokenStore, error) {
+	if !cfg.HasOAuthTokenStore() {
+		return nil, nil
+	}
+
+	kmsClient, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewFirestoreTokenStore(firestoreClient, kmsClient, cfg.OAuthTokenKMSKeyName), nil
+}
+
+// newOCRAsyncConfig builds the handler.AsyncConfig used to route large OCR
+// batches through the async job queue. It returns the zero value (not an
+// error) when async batching isn't configured, so deployments without it
+// simply fall back to always processing batches inline.
+func newOCRAsyncConfig(ctx context.Context, cfg *config.Config, firestoreClient *firestore.Client) (handler.AsyncConfig, error) {
+	if !cfg.HasAsyncOCR() {
+		return handler.AsyncConfig{}, nil
+	}
+
+	gcsClient, err := storage.NewClient(ctx)
+	if err != nil {
+		return handler.AsyncConfig{}, err
+	}
+
+	pubsubClient, err := pubsub.NewClient(ctx, cfg.GoogleProjectID)
+	if err != nil {
+		return handler.AsyncConfig{}, err
+	}
+
+	return handler.AsyncConfig{
+		GCSClient: gcsClient,
+		GCSBucket: cfg.OCRGCSBucket,
+		JobStore:  ocr.NewFirestoreStore(firestoreClient),
+		Queue:     ocr.NewPubSubQueue(pubsubClient.Topic(cfg.OCRPubSubTopicID)),
+	}, nil
+}
+
+// newRateLimitStore builds the rate limit Store selected by RATE_LIMIT_BACKEND.
+// It also returns the underlying *redis.Client (nil for the in-memory store)
+// so callers can use it for readiness checks.
+func newRateLimitStore(cfg *config.Config) (middleware.Store, *redis.Client, error) {
+	switch cfg.RateLimitBackend {
+	case "redis":
+		if cfg.RateLimitRedisAddr == "" {
+			return nil, nil, fmt.Errorf("RATE_LIMIT_REDIS_ADDR is required when RATE_LIMIT_BACKEND=redis")
+		}
+		client := redis.NewClient(&redis.Options{Addr: cfg.RateLimitRedisAddr})
+		return middleware.NewRedisStore(client), client, nil
+	case "memory", "":
+		return middleware.NewMemoryStore(), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", cfg.RateLimitBackend)
+	}
+}
+
+// newRevocationStore builds the auth.TokenRevocationStore used to reject
+// logged-out/revoked tokens. It reuses redisClient (the rate limiter's, if
+// RATE_LIMIT_BACKEND=redis) so revocations are shared across every instance;
+// with no Redis client configured, it falls back to an in-memory store that
+// only this instance sees.
+func newRevocationStore(redisClient *redis.Client) auth.TokenRevocationStore {
+	if redisClient != nil {
+		return auth.NewRedisRevocationStore(redisClient)
+	}
+	return auth.NewMemoryRevocationStore()
+}
+
+// newRefreshTokenStore builds the auth.RefreshTokenStore used to detect a
+// stolen refresh token being replayed (see auth.JWTService.RefreshTokens).
+// Like newRevocationStore, it reuses redisClient when available so reuse
+// detection holds across every instance, falling back to an in-memory store
+// that only this instance sees.
+func newRefreshTokenStore(redisClient *redis.Client) auth.RefreshTokenStore {
+	if redisClient != nil {
+		return auth.NewRedisRefreshTokenStore(redisClient)
+	}
+	return auth.NewMemoryRefreshTokenStore()
+}
+
+// newJWTKeySet builds the auth.KeySet JWTService signs and verifies tokens
+// with, from JWTAlg/JWTSecret/JWTPrivateKeyPath/JWTPreviousKeys. Previous
+// keys are loaded with the same algorithm as the current one and kept around
+// only so tokens signed before a rotation still validate until they expire.
+func newJWTKeySet(cfg *config.Config) (*auth.KeySet, error) {
+	primaryMaterial := cfg.JWTSecret
+	if cfg.JWTAlg != "HS256" {
+		primaryMaterial = cfg.JWTPrivateKeyPath
+	}
+
+	primary, err := auth.LoadSigningKey(cfg.JWTAlg, "", primaryMaterial)
+	if err != nil {
+		return nil, fmt.Errorf("load primary JWT signing key: %w", err)
+	}
+
+	previous := make([]auth.SigningKey, 0, len(cfg.JWTPreviousKeys))
+	for _, material := range cfg.JWTPreviousKeys {
+		key, err := auth.LoadSigningKey(cfg.JWTAlg, "", material)
+		if err != nil {
+			return nil, fmt.Errorf("load previous JWT signing key: %w", err)
+		}
+		previous = append(previous, key)
+	}
+
+	return auth.NewKeySet(primary, previous...), nil
+}
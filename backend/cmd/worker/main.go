@@ -0,0 +1,83 @@
+// Command worker runs the OCR async job worker: it subscribes to the Pub/Sub
+// topic the API server dispatches batch jobs to and drives each one to
+// completion. It's deployed separately from the API server so the two scale
+// independently.
+package main
+
+import (
+	"context"
+	"os"
+
+	documentai "cloud.google.com/go/documentai/apiv1"
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
+
+	"github.com/stellar/torifune/backend/internal/config"
+	"github.com/stellar/torifune/backend/internal/logging"
+	"github.com/stellar/torifune/backend/internal/ocr"
+	"github.com/stellar/torifune/backend/internal/repository"
+)
+
+func main() {
+	logger := logging.New()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	if !cfg.HasAsyncOCR() || cfg.OCRPubSubSubscriptionID == "" {
+		logger.Error("async OCR is not configured; set OCR_GCS_BUCKET, OCR_PUBSUB_TOPIC_ID, and OCR_PUBSUB_SUBSCRIPTION_ID")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	firestoreClient, err := firestore.NewClient(ctx, cfg.GoogleProjectID)
+	if err != nil {
+		logger.Error("failed to create Firestore client", "error", err)
+		os.Exit(1)
+	}
+	defer firestoreClient.Close()
+
+	gcsClient, err := storage.NewClient(ctx)
+	if err != nil {
+		logger.Error("failed to create GCS client", "error", err)
+		os.Exit(1)
+	}
+	defer gcsClient.Close()
+
+	pubsubClient, err := pubsub.NewClient(ctx, cfg.GoogleProjectID)
+	if err != nil {
+		logger.Error("failed to create Pub/Sub client", "error", err)
+		os.Exit(1)
+	}
+	defer pubsubClient.Close()
+
+	documentAIClient, err := documentai.NewDocumentProcessorClient(ctx)
+	if err != nil {
+		logger.Error("failed to create Document AI client", "error", err)
+		os.Exit(1)
+	}
+	defer documentAIClient.Close()
+
+	schemaRepo := repository.NewFirestoreSchemaRepository(firestoreClient)
+	processor := ocr.NewDocumentAIProcessor(documentAIClient, cfg.GoogleProjectID, cfg.GoogleLocation, cfg.DocumentAIFormParserID, cfg.DocumentAIProcessorID, schemaRepo)
+	usageRepo := repository.NewFirestoreUsageRepository(firestoreClient, cfg.FreeTierLimit)
+
+	w := ocr.NewWorker(
+		pubsubClient.Subscription(cfg.OCRPubSubSubscriptionID),
+		gcsClient,
+		ocr.NewFirestoreStore(firestoreClient),
+		processor,
+		usageRepo,
+		logger,
+	)
+
+	logger.Info("worker starting", "subscription", cfg.OCRPubSubSubscriptionID)
+	if err := w.Run(ctx); err != nil {
+		logger.Error("worker stopped", "error", err)
+		os.Exit(1)
+	}
+}
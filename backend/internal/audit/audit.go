@@ -0,0 +1,67 @@
+// Package audit provides a cross-cutting trail of security-relevant events
+// (authentication, OCR usage, quota decisions) for compliance and incident
+// response.
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Event represents a single audit log entry.
+type Event struct {
+	// Timestamp is when the event occurred.
+	Timestamp time.Time `firestore:"timestamp" json:"timestamp"`
+	// ActorID identifies who performed the action (user ID, service account, or "anonymous").
+	ActorID string `firestore:"actorId" json:"actorId"`
+	// ActorType is "user", "organization", or "system".
+	ActorType string `firestore:"actorType" json:"actorType"`
+	// Action is a short machine-readable verb, e.g. "auth.login", "ocr.process", "usage.quota_denied".
+	Action string `firestore:"action" json:"action"`
+	// Resource identifies what was acted on, e.g. a user ID or job ID.
+	Resource string `firestore:"resource" json:"resource"`
+	// IP is the client's address.
+	IP string `firestore:"ip" json:"ip"`
+	// UserAgent is the client's User-Agent header.
+	UserAgent string `firestore:"userAgent" json:"userAgent"`
+	// RequestID correlates this event with request logs.
+	RequestID string `firestore:"requestId" json:"requestId"`
+	// Outcome is "success", "failure", or "denied".
+	Outcome string `firestore:"outcome" json:"outcome"`
+	// Metadata holds action-specific extra fields.
+	Metadata map[string]any `firestore:"metadata" json:"metadata"`
+}
+
+// Outcome values used across callers for consistency.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// Auditor records audit events. Implementations must not block request
+// handling on a slow or unavailable log sink; LogXEvent should itself decide
+// whether to log synchronously or best-effort.
+type Auditor interface {
+	// LogAuthEvent records an authentication-related event (login, refresh, logout).
+	LogAuthEvent(ctx context.Context, event Event) error
+	// LogOCREvent records an OCR processing event (process, batch, quota-denied).
+	LogOCREvent(ctx context.Context, event Event) error
+	// LogUsageEvent records a usage/quota-related event.
+	LogUsageEvent(ctx context.Context, event Event) error
+}
+
+// NoopAuditor discards every event. It's used when AUDIT_LOGGING_ENABLED is false.
+type NoopAuditor struct{}
+
+// LogAuthEvent implements Auditor.
+func (NoopAuditor) LogAuthEvent(ctx context.Context, event Event) error { return nil }
+
+// LogOCREvent implements Auditor.
+func (NoopAuditor) LogOCREvent(ctx context.Context, event Event) error { return nil }
+
+// LogUsageEvent implements Auditor.
+func (NoopAuditor) LogUsageEvent(ctx context.Context, event Event) error { return nil }
+
+// Ensure NoopAuditor implements Auditor.
+var _ Auditor = NoopAuditor{}
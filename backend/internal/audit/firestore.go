@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// FirestoreAuditor writes audit events to a Firestore collection.
+type FirestoreAuditor struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreAuditor creates a Firestore-backed Auditor writing to "audit_logs".
+func NewFirestoreAuditor(client *firestore.Client) *FirestoreAuditor {
+	return &FirestoreAuditor{
+		client:     client,
+		collection: "audit_logs",
+	}
+}
+
+func (a *FirestoreAuditor) write(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	_, _, err := a.client.Collection(a.collection).Add(ctx, event)
+	if err != nil {
+		// Audit logging must not take down the request path it's observing;
+		// log here and return the error, which the handlers discard, so a
+		// Firestore hiccup doesn't turn into a 500 on login/OCR.
+		log.Printf("audit: failed to write event action=%s actor=%s: %v", event.Action, event.ActorID, err)
+		return fmt.Errorf("audit: write event: %w", err)
+	}
+
+	return nil
+}
+
+// LogAuthEvent implements Auditor.
+func (a *FirestoreAuditor) LogAuthEvent(ctx context.Context, event Event) error {
+	return a.write(ctx, event)
+}
+
+// LogOCREvent implements Auditor.
+func (a *FirestoreAuditor) LogOCREvent(ctx context.Context, event Event) error {
+	return a.write(ctx, event)
+}
+
+// LogUsageEvent implements Auditor.
+func (a *FirestoreAuditor) LogUsageEvent(ctx context.Context, event Event) error {
+	return a.write(ctx, event)
+}
+
+// Ensure FirestoreAuditor implements Auditor.
+var _ Auditor = (*FirestoreAuditor)(nil)
+
+// Lister is implemented by Auditors that support querying back their history,
+// e.g. for an admin-facing audit log viewer.
+type Lister interface {
+	List(ctx context.Context, filter ListFilter) (*ListResult, error)
+}
+
+// ListFilter narrows down the audit events returned by List.
+type ListFilter struct {
+	ActorID string
+	Action  string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	Cursor  string // opaque, the document ID to start after
+}
+
+// ListResult is a page of audit events plus the cursor to fetch the next page.
+type ListResult struct {
+	Events     []Event
+	NextCursor string
+}
+
+// List returns audit events matching filter, ordered most-recent first, using
+// cursor-based pagination.
+func (a *FirestoreAuditor) List(ctx context.Context, filter ListFilter) (*ListResult, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := a.client.Collection(a.collection).Query
+
+	if filter.ActorID != "" {
+		query = query.Where("actorId", "==", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action", "==", filter.Action)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("timestamp", ">=", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("timestamp", "<=", filter.Until)
+	}
+
+	query = query.OrderBy("timestamp", firestore.Desc).Limit(limit + 1)
+
+	if filter.Cursor != "" {
+		cursorDoc, err := a.client.Collection(a.collection).Doc(filter.Cursor).Get(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("audit: resolve cursor: %w", err)
+		}
+		query = query.StartAfter(cursorDoc)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("audit: list events: %w", err)
+	}
+
+	result := &ListResult{Events: make([]Event, 0, len(docs))}
+	for i, doc := range docs {
+		if i == limit {
+			result.NextCursor = docs[limit-1].Ref.ID
+			break
+		}
+		var event Event
+		if err := doc.DataTo(&event); err != nil {
+			return nil, fmt.Errorf("audit: decode event: %w", err)
+		}
+		result.Events = append(result.Events, event)
+	}
+
+	return result, nil
+}
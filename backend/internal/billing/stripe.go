@@ -0,0 +1,64 @@
+// Package billing reports billable usage to external billing providers.
+package billing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stripeUsageRecordURL is Stripe's metered-billing usage record endpoint,
+// recording quantity against a subscription item for the current billing
+// period.
+const stripeUsageRecordURL = "https://api.stripe.com/v1/subscription_items/%s/usage_records"
+
+// StripeReporter reports overage units to Stripe metered billing via the
+// usage records API. It implements usage.BillingReporter.
+type StripeReporter struct {
+	secretKey  string
+	httpClient *http.Client
+}
+
+// NewStripeReporter creates a StripeReporter authenticating with secretKey
+// (a Stripe restricted or secret API key scoped to usage record writes).
+func NewStripeReporter(secretKey string) *StripeReporter {
+	return &StripeReporter{
+		secretKey:  secretKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ReportUsage records quantity billable units against subscriptionItemID for
+// the current billing period.
+func (r *StripeReporter) ReportUsage(ctx context.Context, subscriptionItemID string, quantity int) error {
+	data := url.Values{}
+	data.Set("quantity", strconv.Itoa(quantity))
+	data.Set("timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	data.Set("action", "increment")
+
+	endpoint := fmt.Sprintf(stripeUsageRecordURL, subscriptionItemID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("billing: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(r.secretKey, "")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("billing: report usage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("billing: unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
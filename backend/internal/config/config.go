@@ -1,24 +1,81 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// ExtraJWTIssuer mirrors middleware.ExtraIssuer for the purposes of config
+// parsing; config is a leaf package and can't import middleware, so
+// main.go maps this into a middleware.ExtraIssuer at startup.
+type ExtraJWTIssuer struct {
+	Issuer      string `json:"issuer"`
+	JWKSURL     string `json:"jwks_url"`
+	Audience    string `json:"audience"`
+	UserIDClaim string `json:"user_id_claim"`
+}
+
 // Config holds all configuration values for the application.
 type Config struct {
 	// Google Cloud settings
 	GoogleProjectID       string
 	GoogleLocation        string
 	DocumentAIProcessorID string
+	// DocumentAIFormParserID is a form-parser processor used instead of
+	// DocumentAIProcessorID for PDFs, so structured field extraction is
+	// available for document types that support it. Optional; PDFs fall
+	// back to DocumentAIProcessorID when unset.
+	DocumentAIFormParserID string
+
+	// OCR async batch processing. Unset (OCRGCSBucket == "") disables async
+	// batching entirely; every batch is then processed inline instead of queued.
+	OCRGCSBucket            string
+	OCRPubSubTopicID        string
+	OCRPubSubSubscriptionID string
 
 	// Authentication
 	JWTSecret string
 
+	// JWTAlg selects the JWT signing algorithm: "HS256" (default, signing
+	// with JWTSecret) or an asymmetric algorithm ("RS256", "ES256", ...), in
+	// which case JWTPrivateKeyPath must point to a PEM-encoded private key
+	// instead. See auth.LoadSigningKey.
+	JWTAlg string
+	// JWTPrivateKeyPath is the PEM-encoded private key JWTAlg signs new
+	// tokens with. Unused when JWTAlg is HS256.
+	JWTPrivateKeyPath string
+	// JWTPreviousKeys are prior signing keys kept around only so tokens
+	// signed before a rotation still validate until they naturally expire:
+	// PEM file paths for an asymmetric JWTAlg, or raw secrets for HS256. Each
+	// is loaded with the same JWTAlg as the current key and assigned a kid
+	// derived from its own key material (see auth.LoadSigningKey).
+	JWTPreviousKeys []string
+
+	// ExtraJWTIssuers lists trusted external token issuers (CI, service
+	// accounts, third-party OIDC providers) verified via JWKS instead of
+	// JWTSecret, so machine-to-machine callers can hit protected endpoints
+	// without going through the OAuth login flow. Parsed from
+	// EXTRA_JWT_ISSUERS as a JSON array; see middleware.ExtraIssuer.
+	ExtraJWTIssuers []ExtraJWTIssuer
+
 	// CORS
 	FrontendURL string
 
+	// CORSAllowedOrigins lists origins (exact or wildcard-subdomain, e.g.
+	// "https://*.torifune.app") allowed to make cross-origin requests. If
+	// empty, FrontendURL is used as the sole allowed origin.
+	CORSAllowedOrigins []string
+	// CORSAllowPrivateNetwork grants Chrome's Private Network Access
+	// preflight (Access-Control-Request-Private-Network) when requested.
+	CORSAllowPrivateNetwork bool
+
 	// OAuth providers
 	GoogleClientID     string
 	GoogleClientSecret string
@@ -27,30 +84,154 @@ type Config struct {
 	MicrosoftClientSecret string
 	MicrosoftTenantID     string
 
+	// GoogleDeviceAuthEndpoint/MicrosoftDeviceAuthEndpoint override the
+	// provider's device-authorization endpoint (see auth.DeviceAuthProvider).
+	// Leaving either unset uses the provider's standard endpoint.
+	GoogleDeviceAuthEndpoint    string
+	MicrosoftDeviceAuthEndpoint string
+
+	// PKCERequiredProviders lists provider names (e.g. "google") whose
+	// /api/v1/auth/login/{provider} requests must supply their own PKCE
+	// code_challenge instead of falling back to the server-generated-PKCE
+	// cookie flow. Intended for a public client (the frontend SPA, a mobile
+	// app) that manages its own code_verifier rather than relying on a
+	// cookie. See handler.AuthHandler.Login.
+	PKCERequiredProviders []string
+
 	SlackClientID     string
 	SlackClientSecret string
+	// SlackSigningSecret verifies the X-Slack-Signature header on incoming
+	// Events API webhooks. Required for the /api/v1/slack/events endpoint;
+	// leaving it unset disables the endpoint (every request is rejected).
+	SlackSigningSecret string
+	// SlackBotScopes are the workspace-level (bot token) OAuth scopes
+	// requested during the Slack OAuth flow. Defaults to
+	// auth.DefaultSlackBotScopes when unset.
+	SlackBotScopes []string
+
+	// OAuthTokenKMSKeyName is the full resource name of the Cloud KMS key
+	// ("projects/p/locations/l/keyRings/r/cryptoKeys/k") used to wrap the
+	// data encryption key for stored OAuth tokens (see auth.TokenStore).
+	// Required for providers whose tokens need refreshing (currently Slack);
+	// leaving it unset disables persisting/refreshing tokens entirely.
+	OAuthTokenKMSKeyName string
+
+	// OIDCRequireVerifiedEmail rejects a verified id_token (see
+	// auth.OIDCVerifier) whose email_verified claim is false. Defaults to
+	// true; deployments that trust their configured providers' unverified
+	// addresses can disable it.
+	OIDCRequireVerifiedEmail bool
 
 	// Application settings
 	FreeTierLimit int
+
+	// StripeSecretKey authenticates metered-billing usage record writes for
+	// pro/enterprise overage (see usage.PolicyEngine, billing.StripeReporter).
+	// Leaving it unset still enforces tier limits but skips Stripe reporting.
+	StripeSecretKey string
+
+	// Entitlements/licensing
+	LicenseFilePath          string
+	LicensePublicKeyPath     string
+	LicenseFirestoreEnabled  bool
+	LicenseResyncIntervalSec int
+
+	// Rate limiting
+	RateLimitBackend      string // "memory" or "redis"
+	RateLimitRedisAddr    string
+	RateLimitPerMinute    int
+	RateLimitOCRPerMinute int
+
+	// TrustedProxies lists CIDR ranges (e.g. the load balancer in front of the
+	// service) whose X-Forwarded-For/Forwarded entries are trusted when
+	// determining the client IP. Defaults (loopback and RFC1918) are always
+	// trusted in addition to this list; see middleware.SetTrustedProxies.
+	TrustedProxies []string
+
+	// Audit logging
+	AuditLoggingEnabled bool
+
+	// ShutdownTimeoutSec bounds how long graceful shutdown waits for
+	// in-flight requests to finish draining before forcing the server closed.
+	ShutdownTimeoutSec int
+
+	// ConfigWatchIntervalSec is how often Watch re-runs Load to pick up
+	// values that can change without a restart, e.g. a GCP Secret Manager
+	// secret (see GCPSecretManagerSource) getting a new version.
+	ConfigWatchIntervalSec int
 }
 
-// Load reads configuration from environment variables.
-// It returns an error if required variables are missing.
+// Load reads configuration from a layered Source chain: the process
+// environment, then a .env file (CONFIG_DOTENV_PATH, default ".env"), then
+// a YAML file (CONFIG_FILE, if set), each falling back to the next when a
+// key is unset. If GOOGLE_PROJECT_ID is configured, any value the chain
+// yields is additionally resolved for a "sm://" GCP Secret Manager
+// reference (see GCPSecretManagerSource). It returns an error if required
+// variables are missing.
 func Load() (*Config, error) {
+	source, closeSource, err := buildSource()
+	if err != nil {
+		return nil, fmt.Errorf("build configuration source: %w", err)
+	}
+	defer closeSource()
+
 	cfg := &Config{
-		GoogleProjectID:       os.Getenv("GOOGLE_PROJECT_ID"),
-		GoogleLocation:        getEnvOrDefault("GOOGLE_LOCATION", "us"),
-		DocumentAIProcessorID: os.Getenv("DOCUMENT_AI_PROCESSOR_ID"),
-		JWTSecret:             os.Getenv("JWT_SECRET"),
-		FrontendURL:           os.Getenv("FRONTEND_URL"),
-		GoogleClientID:        os.Getenv("GOOGLE_CLIENT_ID"),
-		GoogleClientSecret:    os.Getenv("GOOGLE_CLIENT_SECRET"),
-		MicrosoftClientID:     os.Getenv("MICROSOFT_CLIENT_ID"),
-		MicrosoftClientSecret: os.Getenv("MICROSOFT_CLIENT_SECRET"),
-		MicrosoftTenantID:     os.Getenv("MICROSOFT_TENANT_ID"),
-		SlackClientID:         os.Getenv("SLACK_CLIENT_ID"),
-		SlackClientSecret:     os.Getenv("SLACK_CLIENT_SECRET"),
-		FreeTierLimit:         getEnvAsIntOrDefault("FREE_TIER_LIMIT", 300),
+		GoogleProjectID:        getEnvOrDefault(source, "GOOGLE_PROJECT_ID", ""),
+		GoogleLocation:         getEnvOrDefault(source, "GOOGLE_LOCATION", "us"),
+		DocumentAIProcessorID:  getEnvOrDefault(source, "DOCUMENT_AI_PROCESSOR_ID", ""),
+		DocumentAIFormParserID: getEnvOrDefault(source, "DOCUMENT_AI_FORM_PARSER_ID", ""),
+
+		OCRGCSBucket:            getEnvOrDefault(source, "OCR_GCS_BUCKET", ""),
+		OCRPubSubTopicID:        getEnvOrDefault(source, "OCR_PUBSUB_TOPIC_ID", ""),
+		OCRPubSubSubscriptionID: getEnvOrDefault(source, "OCR_PUBSUB_SUBSCRIPTION_ID", ""),
+
+		JWTSecret:         getEnvOrDefault(source, "JWT_SECRET", ""),
+		JWTAlg:            getEnvOrDefault(source, "JWT_ALG", "HS256"),
+		JWTPrivateKeyPath: getEnvOrDefault(source, "JWT_PRIVATE_KEY_PATH", ""),
+		JWTPreviousKeys:   getEnvAsListOrDefault(source, "JWT_PREVIOUS_KEYS", nil),
+		ExtraJWTIssuers:   getEnvAsExtraJWTIssuers(source, "EXTRA_JWT_ISSUERS"),
+		FrontendURL:       getEnvOrDefault(source, "FRONTEND_URL", ""),
+
+		CORSAllowedOrigins:      getEnvAsListOrDefault(source, "CORS_ALLOWED_ORIGINS", nil),
+		CORSAllowPrivateNetwork: getEnvOrDefault(source, "CORS_ALLOW_PRIVATE_NETWORK", "false") == "true",
+
+		GoogleClientID:        getEnvOrDefault(source, "GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret:    getEnvOrDefault(source, "GOOGLE_CLIENT_SECRET", ""),
+		MicrosoftClientID:     getEnvOrDefault(source, "MICROSOFT_CLIENT_ID", ""),
+		MicrosoftClientSecret: getEnvOrDefault(source, "MICROSOFT_CLIENT_SECRET", ""),
+		MicrosoftTenantID:     getEnvOrDefault(source, "MICROSOFT_TENANT_ID", ""),
+
+		GoogleDeviceAuthEndpoint:    getEnvOrDefault(source, "GOOGLE_DEVICE_AUTH_ENDPOINT", ""),
+		MicrosoftDeviceAuthEndpoint: getEnvOrDefault(source, "MICROSOFT_DEVICE_AUTH_ENDPOINT", ""),
+		PKCERequiredProviders:       getEnvAsListOrDefault(source, "PKCE_REQUIRED_PROVIDERS", nil),
+
+		SlackClientID:        getEnvOrDefault(source, "SLACK_CLIENT_ID", ""),
+		SlackClientSecret:    getEnvOrDefault(source, "SLACK_CLIENT_SECRET", ""),
+		SlackSigningSecret:   getEnvOrDefault(source, "SLACK_SIGNING_SECRET", ""),
+		SlackBotScopes:       getEnvAsListOrDefault(source, "SLACK_BOT_SCOPES", nil),
+		OAuthTokenKMSKeyName: getEnvOrDefault(source, "OAUTH_TOKEN_KMS_KEY_NAME", ""),
+
+		OIDCRequireVerifiedEmail: getEnvOrDefault(source, "OIDC_REQUIRE_VERIFIED_EMAIL", "true") == "true",
+
+		FreeTierLimit:   getEnvAsIntOrDefault(source, "FREE_TIER_LIMIT", 300),
+		StripeSecretKey: getEnvOrDefault(source, "STRIPE_SECRET_KEY", ""),
+
+		LicenseFilePath:          getEnvOrDefault(source, "LICENSE_FILE_PATH", ""),
+		LicensePublicKeyPath:     getEnvOrDefault(source, "LICENSE_PUBLIC_KEY_PATH", ""),
+		LicenseFirestoreEnabled:  getEnvOrDefault(source, "LICENSE_FIRESTORE_ENABLED", "false") == "true",
+		LicenseResyncIntervalSec: getEnvAsIntOrDefault(source, "LICENSE_RESYNC_INTERVAL_SEC", 300),
+
+		RateLimitBackend:      getEnvOrDefault(source, "RATE_LIMIT_BACKEND", "memory"),
+		RateLimitRedisAddr:    getEnvOrDefault(source, "RATE_LIMIT_REDIS_ADDR", ""),
+		RateLimitPerMinute:    getEnvAsIntOrDefault(source, "RATE_LIMIT_PER_MINUTE", 100),
+		RateLimitOCRPerMinute: getEnvAsIntOrDefault(source, "RATE_LIMIT_OCR_PER_MINUTE", 20),
+
+		TrustedProxies: getEnvAsListOrDefault(source, "TRUSTED_PROXIES", nil),
+
+		AuditLoggingEnabled: getEnvOrDefault(source, "AUDIT_LOGGING_ENABLED", "true") == "true",
+
+		ShutdownTimeoutSec:     getEnvAsIntOrDefault(source, "SHUTDOWN_TIMEOUT_SEC", 30),
+		ConfigWatchIntervalSec: getEnvAsIntOrDefault(source, "CONFIG_WATCH_INTERVAL_SEC", 300),
 	}
 
 	if err := cfg.validate(); err != nil {
@@ -60,6 +241,96 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// buildSource composes Load's Source chain in priority order: the process
+// environment, a .env file, and (if CONFIG_FILE is set) a YAML file. If
+// GOOGLE_PROJECT_ID is set, the chain is wrapped so any "sm://<secret-id>"
+// value it yields is resolved against GCP Secret Manager. GOOGLE_PROJECT_ID
+// itself must come from the process environment, since it's needed to
+// construct the Secret Manager client before the rest of the chain can be
+// read through it. The returned closer releases the Secret Manager client,
+// if one was created, and must be called once the source is no longer
+// needed.
+func buildSource() (Source, func(), error) {
+	dotenvPath := os.Getenv("CONFIG_DOTENV_PATH")
+	if dotenvPath == "" {
+		dotenvPath = ".env"
+	}
+	dotenv, err := NewDotEnvSource(dotenvPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain := MultiSource{EnvSource{}, dotenv}
+
+	if yamlPath := os.Getenv("CONFIG_FILE"); yamlPath != "" {
+		yamlSource, err := NewYAMLSource(yamlPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		chain = append(chain, yamlSource)
+	}
+
+	projectID := os.Getenv("GOOGLE_PROJECT_ID")
+	if projectID == "" {
+		return chain, func() {}, nil
+	}
+
+	sm, err := NewGCPSecretManagerSource(context.Background(), chain, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sm, func() { _ = sm.Close() }, nil
+}
+
+// Watch reloads configuration via Load every interval until ctx is
+// canceled, calling onChange with the freshly loaded Config whenever any
+// value differs from current. It's intended for values backed by GCP
+// Secret Manager (see GCPSecretManagerSource) that can rotate without a
+// restart, e.g. letting auth.JWTService swap its signing key in place via
+// SetKeySet. A failed reload is logged and retried on the next tick rather
+// than treated as fatal.
+func Watch(ctx context.Context, interval time.Duration, current *Config, onChange func(*Config)) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+
+			next, err := Load()
+			if err != nil {
+				log.Printf("config: reload failed, keeping previous configuration: %v", err)
+				continue
+			}
+			if !reflect.DeepEqual(next, current) {
+				current = next
+				onChange(current)
+			}
+		}
+	}()
+}
+
+// Redacted returns a copy of c with its JWTSecret and OAuth client secret
+// fields masked, safe to log or include in an error message.
+func (c *Config) Redacted() Config {
+	redacted := *c
+	redacted.JWTSecret = redactSecret(c.JWTSecret)
+	redacted.GoogleClientSecret = redactSecret(c.GoogleClientSecret)
+	redacted.MicrosoftClientSecret = redactSecret(c.MicrosoftClientSecret)
+	redacted.SlackClientSecret = redactSecret(c.SlackClientSecret)
+	return redacted
+}
+
+// redactSecret masks a secret value, keeping only enough to confirm one
+// was set at all.
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
 // validate checks that all required configuration values are present.
 func (c *Config) validate() error {
 	var missing []string
@@ -70,8 +341,12 @@ func (c *Config) validate() error {
 	if c.DocumentAIProcessorID == "" {
 		missing = append(missing, "DOCUMENT_AI_PROCESSOR_ID")
 	}
-	if c.JWTSecret == "" {
-		missing = append(missing, "JWT_SECRET")
+	if c.JWTAlg == "HS256" {
+		if c.JWTSecret == "" {
+			missing = append(missing, "JWT_SECRET")
+		}
+	} else if c.JWTPrivateKeyPath == "" {
+		missing = append(missing, "JWT_PRIVATE_KEY_PATH")
 	}
 	if c.FrontendURL == "" {
 		missing = append(missing, "FRONTEND_URL")
@@ -99,22 +374,70 @@ func (c *Config) HasSlackOAuth() bool {
 	return c.SlackClientID != "" && c.SlackClientSecret != ""
 }
 
-// getEnvOrDefault returns the value of an environment variable,
-// or a default value if the variable is not set.
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// HasAsyncOCR returns true if async batch OCR (staging in GCS, dispatching
+// via Pub/Sub) is fully configured.
+func (c *Config) HasAsyncOCR() bool {
+	return c.OCRGCSBucket != "" && c.OCRPubSubTopicID != ""
+}
+
+// HasOAuthTokenStore returns true if persisting and refreshing stored OAuth
+// tokens (see auth.TokenStore) is configured.
+func (c *Config) HasOAuthTokenStore() bool {
+	return c.OAuthTokenKMSKeyName != ""
+}
+
+// getEnvOrDefault returns source's value for key, or a default value if
+// it's unset.
+func getEnvOrDefault(source Source, key, defaultValue string) string {
+	if value, ok := source.Get(key); ok && value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-// getEnvAsIntOrDefault returns the value of an environment variable as an integer,
-// or a default value if the variable is not set or cannot be parsed.
-func getEnvAsIntOrDefault(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+// getEnvAsIntOrDefault returns source's value for key as an integer, or a
+// default value if it's unset or can't be parsed.
+func getEnvAsIntOrDefault(source Source, key string, defaultValue int) int {
+	if value, ok := source.Get(key); ok && value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
 	}
 	return defaultValue
 }
+
+// getEnvAsExtraJWTIssuers parses a JSON array of ExtraJWTIssuer from
+// source's value for key. Returns nil if the value is unset or isn't valid
+// JSON, so a malformed value degrades to "no extra issuers trusted" rather
+// than failing startup.
+func getEnvAsExtraJWTIssuers(source Source, key string) []ExtraJWTIssuer {
+	value, ok := source.Get(key)
+	if !ok || value == "" {
+		return nil
+	}
+
+	var issuers []ExtraJWTIssuer
+	if err := json.Unmarshal([]byte(value), &issuers); err != nil {
+		return nil
+	}
+	return issuers
+}
+
+// getEnvAsListOrDefault returns source's value for key split on commas, or
+// a default value if it's unset. Entries are trimmed of surrounding
+// whitespace and empty entries are dropped.
+func getEnvAsListOrDefault(source Source, key string, defaultValue []string) []string {
+	value, ok := source.Get(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
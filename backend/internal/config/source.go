@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Source resolves a single configuration value by key, the way os.Getenv
+// does, so Load can layer multiple origins (the process environment, a
+// .env file, a YAML file, GCP Secret Manager) without every call site
+// caring which one actually supplied the value.
+type Source interface {
+	// Get returns the value for key and whether it was set at all.
+	Get(key string) (string, bool)
+}
+
+// EnvSource reads from the process environment.
+type EnvSource struct{}
+
+func (EnvSource) Get(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// MultiSource tries each of its sources in order and returns the first
+// value found, so Load can express a priority chain (e.g. the process
+// environment overriding a checked-in YAML file).
+type MultiSource []Source
+
+func (m MultiSource) Get(key string) (string, bool) {
+	for _, source := range m {
+		if value, ok := source.Get(key); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// DotEnvSource reads KEY=VALUE pairs from a .env-style file (see
+// github.com/joho/godotenv) without mutating the process environment, so
+// it can be layered below EnvSource instead of racing with that package's
+// own process-wide Load side effect.
+type DotEnvSource struct {
+	values map[string]string
+}
+
+// NewDotEnvSource reads path's .env-style contents. A missing file isn't an
+// error: the returned DotEnvSource just resolves nothing, so a deployment
+// that doesn't ship one falls through to whatever source comes after it.
+func NewDotEnvSource(path string) (*DotEnvSource, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &DotEnvSource{values: map[string]string{}}, nil
+		}
+		return nil, fmt.Errorf("read dotenv file %s: %w", path, err)
+	}
+	return &DotEnvSource{values: values}, nil
+}
+
+func (s *DotEnvSource) Get(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// YAMLSource reads configuration values from a flat "KEY: value" YAML
+// file, for deployments that prefer a checked-in config file over setting
+// a long list of environment variables.
+type YAMLSource struct {
+	values map[string]string
+}
+
+// NewYAMLSource parses path as a flat YAML map of string keys to string
+// values. A missing file isn't an error, matching NewDotEnvSource.
+func NewYAMLSource(path string) (*YAMLSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &YAMLSource{values: map[string]string{}}, nil
+		}
+		return nil, fmt.Errorf("read yaml config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parse yaml config file %s: %w", path, err)
+	}
+	return &YAMLSource{values: values}, nil
+}
+
+func (s *YAMLSource) Get(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// gcpSecretPrefix marks a value as a reference to a GCP Secret Manager
+// secret (the secret's ID, not its full resource name) rather than a
+// literal value, e.g. "sm://jwt-signing-key" resolves to the latest
+// version of the "jwt-signing-key" secret.
+const gcpSecretPrefix = "sm://"
+
+// GCPSecretManagerSource wraps another Source and transparently resolves
+// any "sm://<secret-id>" value it returns against GCP Secret Manager, so a
+// deployment can put e.g. "sm://jwt-signing-key" in its .env or YAML file
+// instead of the literal secret.
+type GCPSecretManagerSource struct {
+	inner     Source
+	client    *secretmanager.Client
+	projectID string
+}
+
+// NewGCPSecretManagerSource wraps inner, resolving sm:// references
+// against projectID. Callers must call Close once done with it.
+func NewGCPSecretManagerSource(ctx context.Context, inner Source, projectID string) (*GCPSecretManagerSource, error) {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create secret manager client: %w", err)
+	}
+	return &GCPSecretManagerSource{inner: inner, client: client, projectID: projectID}, nil
+}
+
+func (s *GCPSecretManagerSource) Get(key string) (string, bool) {
+	value, ok := s.inner.Get(key)
+	if !ok || !strings.HasPrefix(value, gcpSecretPrefix) {
+		return value, ok
+	}
+
+	resolved, err := s.resolve(strings.TrimPrefix(value, gcpSecretPrefix))
+	if err != nil {
+		return "", false
+	}
+	return resolved, true
+}
+
+// resolve fetches secretID's latest version from Secret Manager.
+func (s *GCPSecretManagerSource) resolve(secretID string) (string, error) {
+	req := &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", s.projectID, secretID),
+	}
+	result, err := s.client.AccessSecretVersion(context.Background(), req)
+	if err != nil {
+		return "", fmt.Errorf("access secret %s: %w", secretID, err)
+	}
+	return string(result.Payload.Data), nil
+}
+
+// Close releases the underlying Secret Manager client.
+func (s *GCPSecretManagerSource) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,246 @@
+// Package entitlements resolves per-user and per-organization feature flags
+// and quota overrides from signed license grants, so that paid tiers can
+// unlock capabilities (unlimited OCR, custom quotas, SSO, audit logging)
+// without a deploy.
+package entitlements
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrLicenseExpired is returned when a license grant's expiry has passed.
+var ErrLicenseExpired = errors.New("entitlements: license expired")
+
+// ErrLicenseInvalid is returned when a license file fails signature or claim validation.
+var ErrLicenseInvalid = errors.New("entitlements: license invalid")
+
+// Grant holds the resolved entitlements for a single user or organization.
+type Grant struct {
+	// SubjectID is the user or organization ID this grant applies to.
+	SubjectID string `json:"subject_id"`
+	// CustomQuota overrides the free-tier monthly limit when > 0.
+	CustomQuota int `json:"custom_quota"`
+	// Features holds boolean feature flags, e.g. "unlimited_ocr", "audit_logging",
+	// "sso_providers", "tier_pro", "tier_enterprise" (see usage.PolicyEngine).
+	Features map[string]bool `json:"features"`
+	// StripeSubscriptionItemID is the metered-billing subscription item
+	// overage units are reported against (see usage.PolicyEngine). Empty on
+	// grants that don't bill overage.
+	StripeSubscriptionItemID string `json:"stripe_subscription_item_id,omitempty"`
+	// ExpiresAt is when this grant stops being honored.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HasFeature reports whether the named feature flag is enabled for this grant.
+func (g Grant) HasFeature(name string) bool {
+	return g.Features[name]
+}
+
+// licenseClaims is the JWT payload shape of a signed license file.
+type licenseClaims struct {
+	jwt.RegisteredClaims
+	Grants []Grant `json:"grants"`
+}
+
+// Source loads the raw signed license document from wherever it's stored.
+type Source interface {
+	// Load returns the signed license JWT as bytes.
+	Load(ctx context.Context) ([]byte, error)
+}
+
+// FileSource reads the license from a local path.
+type FileSource struct {
+	Path string
+}
+
+// Load reads the license file from disk.
+func (s FileSource) Load(ctx context.Context) ([]byte, error) {
+	return os.ReadFile(s.Path)
+}
+
+// FirestoreSource reads the current license from a Firestore `licenses` collection,
+// using the document with the most recent `issuedAt`.
+type FirestoreSource struct {
+	Client     *firestore.Client
+	Collection string
+}
+
+type firestoreLicenseDoc struct {
+	Token    string    `firestore:"token"`
+	IssuedAt time.Time `firestore:"issuedAt"`
+}
+
+// Load fetches the most recently issued license document from Firestore.
+func (s FirestoreSource) Load(ctx context.Context) ([]byte, error) {
+	collection := s.Collection
+	if collection == "" {
+		collection = "licenses"
+	}
+
+	docs, err := s.Client.Collection(collection).
+		OrderBy("issuedAt", firestore.Desc).
+		Limit(1).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("load license from firestore: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("entitlements: no license document found")
+	}
+
+	var doc firestoreLicenseDoc
+	if err := docs[0].DataTo(&doc); err != nil {
+		return nil, fmt.Errorf("decode license document: %w", err)
+	}
+
+	return []byte(doc.Token), nil
+}
+
+// Service atomically holds the current parsed entitlement set and keeps it
+// fresh by periodically re-reading its Source.
+type Service struct {
+	source    Source
+	publicKey *rsa.PublicKey
+
+	grants atomic.Pointer[map[string]Grant]
+}
+
+// NewService creates an entitlements Service that verifies license files against
+// issuerPublicKey (typically baked in at build time) and loads them from source.
+func NewService(source Source, issuerPublicKey *rsa.PublicKey) *Service {
+	s := &Service{
+		source:    source,
+		publicKey: issuerPublicKey,
+	}
+	empty := map[string]Grant{}
+	s.grants.Store(&empty)
+	return s
+}
+
+// Load fetches the license document, verifies it, and atomically swaps in the
+// resulting entitlement set.
+func (s *Service) Load(ctx context.Context) error {
+	raw, err := s.source.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("entitlements: load license: %w", err)
+	}
+
+	claims := &licenseClaims{}
+	_, err = jwt.ParseWithClaims(string(raw), claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("%w: unexpected signing method %v", ErrLicenseInvalid, token.Header["alg"])
+		}
+		return s.publicKey, nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return ErrLicenseExpired
+		}
+		return fmt.Errorf("%w: %v", ErrLicenseInvalid, err)
+	}
+
+	now := time.Now()
+	grants := make(map[string]Grant, len(claims.Grants))
+	for _, g := range claims.Grants {
+		if !g.ExpiresAt.IsZero() && g.ExpiresAt.Before(now) {
+			continue
+		}
+		grants[g.SubjectID] = g
+	}
+
+	s.grants.Store(&grants)
+	return nil
+}
+
+// Get returns the grant for the given subject (user or organization ID).
+// The second return value is false if no active grant exists.
+func (s *Service) Get(subjectID string) (Grant, bool) {
+	grants := *s.grants.Load()
+	g, ok := grants[subjectID]
+	return g, ok
+}
+
+// HasFeature reports whether subjectID has an active grant with the named feature enabled.
+func (s *Service) HasFeature(subjectID, feature string) bool {
+	g, ok := s.Get(subjectID)
+	return ok && g.HasFeature(feature)
+}
+
+// QuotaOverride returns the custom monthly quota for subjectID, if any entitlement
+// grants one. ok is false when no override applies and the caller should fall
+// back to the default free-tier limit.
+func (s *Service) QuotaOverride(subjectID string) (limit int, unlimited, ok bool) {
+	g, found := s.Get(subjectID)
+	if !found {
+		return 0, false, false
+	}
+	if g.HasFeature("unlimited_ocr") {
+		return 0, true, true
+	}
+	if g.CustomQuota > 0 {
+		return g.CustomQuota, false, true
+	}
+	return 0, false, false
+}
+
+// StartResync runs Load on the given interval until ctx is canceled, retrying
+// with exponential backoff (capped) whenever a load fails.
+func (s *Service) StartResync(ctx context.Context, interval time.Duration) {
+	go func() {
+		const (
+			minBackoff = time.Second
+			maxBackoff = 5 * time.Minute
+		)
+		backoff := minBackoff
+
+		for {
+			if err := s.Load(ctx); err != nil {
+				log.Printf("entitlements: resync failed, retrying in %s: %v", backoff, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+				if backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+				continue
+			}
+
+			backoff = minBackoff
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// ParsePublicKeyPEM parses a PEM-encoded RSA public key, as baked into the
+// binary at build time for verifying signed license files.
+func ParsePublicKeyPEM(pemBytes []byte) (*rsa.PublicKey, error) {
+	key, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("entitlements: parse issuer public key: %w", err)
+	}
+	return key, nil
+}
+
+// MarshalGrants is a small helper for tooling that generates license files.
+func MarshalGrants(grants []Grant) ([]byte, error) {
+	return json.Marshal(grants)
+}
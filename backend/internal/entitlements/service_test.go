@@ -0,0 +1,179 @@
+package entitlements
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// stubSource returns a fixed token (or error) on every Load call, and lets
+// tests swap it out mid-test to simulate a license changing on disk.
+type stubSource struct {
+	token []byte
+	err   error
+	loads atomic.Int32
+}
+
+func (s *stubSource) Load(ctx context.Context) ([]byte, error) {
+	s.loads.Add(1)
+	return s.token, s.err
+}
+
+func signLicense(t *testing.T, key *rsa.PrivateKey, grants []Grant, expiresAt time.Time) []byte {
+	t.Helper()
+	claims := &licenseClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Grants: grants,
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign license: %v", err)
+	}
+	return []byte(signed)
+}
+
+func TestServiceLoad(t *testing.T) {
+	issuerKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate issuer key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate other key: %v", err)
+	}
+
+	activeGrant := Grant{SubjectID: "user-1", Features: map[string]bool{"unlimited_ocr": true}}
+	expiredGrant := Grant{SubjectID: "user-2", ExpiresAt: time.Now().Add(-time.Hour)}
+
+	t.Run("valid license populates grants, dropping expired ones", func(t *testing.T) {
+		token := signLicense(t, issuerKey, []Grant{activeGrant, expiredGrant}, time.Now().Add(time.Hour))
+		svc := NewService(&stubSource{token: token}, &issuerKey.PublicKey)
+
+		if err := svc.Load(context.Background()); err != nil {
+			t.Fatalf("Load() = %v, want nil", err)
+		}
+
+		if !svc.HasFeature("user-1", "unlimited_ocr") {
+			t.Error("expected user-1 to have unlimited_ocr")
+		}
+		if _, ok := svc.Get("user-2"); ok {
+			t.Error("expected user-2's expired grant to be dropped")
+		}
+	})
+
+	t.Run("expired license token returns ErrLicenseExpired", func(t *testing.T) {
+		token := signLicense(t, issuerKey, []Grant{activeGrant}, time.Now().Add(-time.Hour))
+		svc := NewService(&stubSource{token: token}, &issuerKey.PublicKey)
+
+		err := svc.Load(context.Background())
+		if err != ErrLicenseExpired {
+			t.Fatalf("Load() = %v, want ErrLicenseExpired", err)
+		}
+	})
+
+	t.Run("license signed by a different key returns ErrLicenseInvalid", func(t *testing.T) {
+		token := signLicense(t, otherKey, []Grant{activeGrant}, time.Now().Add(time.Hour))
+		svc := NewService(&stubSource{token: token}, &issuerKey.PublicKey)
+
+		err := svc.Load(context.Background())
+		if !errors.Is(err, ErrLicenseInvalid) {
+			t.Fatalf("Load() = %v, want ErrLicenseInvalid", err)
+		}
+	})
+
+	t.Run("malformed license returns ErrLicenseInvalid", func(t *testing.T) {
+		svc := NewService(&stubSource{token: []byte("not a jwt")}, &issuerKey.PublicKey)
+
+		err := svc.Load(context.Background())
+		if !errors.Is(err, ErrLicenseInvalid) {
+			t.Fatalf("Load() = %v, want ErrLicenseInvalid", err)
+		}
+	})
+
+	t.Run("source error is propagated without touching grants", func(t *testing.T) {
+		token := signLicense(t, issuerKey, []Grant{activeGrant}, time.Now().Add(time.Hour))
+		svc := NewService(&stubSource{token: token}, &issuerKey.PublicKey)
+		if err := svc.Load(context.Background()); err != nil {
+			t.Fatalf("seed Load() = %v, want nil", err)
+		}
+
+		svc.source = &stubSource{err: context.DeadlineExceeded}
+		if err := svc.Load(context.Background()); err == nil {
+			t.Fatal("Load() = nil, want an error")
+		}
+
+		if !svc.HasFeature("user-1", "unlimited_ocr") {
+			t.Error("a failed reload should leave the previously loaded grants in place")
+		}
+	})
+}
+
+func TestServiceQuotaOverride(t *testing.T) {
+	issuerKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate issuer key: %v", err)
+	}
+
+	grants := []Grant{
+		{SubjectID: "unlimited-user", Features: map[string]bool{"unlimited_ocr": true}},
+		{SubjectID: "custom-quota-user", CustomQuota: 5000},
+		{SubjectID: "plain-user"},
+	}
+	token := signLicense(t, issuerKey, grants, time.Now().Add(time.Hour))
+	svc := NewService(&stubSource{token: token}, &issuerKey.PublicKey)
+	if err := svc.Load(context.Background()); err != nil {
+		t.Fatalf("Load() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		subjectID     string
+		wantLimit     int
+		wantUnlimited bool
+		wantOK        bool
+	}{
+		{"unlimited-user", 0, true, true},
+		{"custom-quota-user", 5000, false, true},
+		{"plain-user", 0, false, false},
+		{"no-grant-user", 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subjectID, func(t *testing.T) {
+			limit, unlimited, ok := svc.QuotaOverride(tt.subjectID)
+			if limit != tt.wantLimit || unlimited != tt.wantUnlimited || ok != tt.wantOK {
+				t.Errorf("QuotaOverride(%q) = (%d, %v, %v), want (%d, %v, %v)",
+					tt.subjectID, limit, unlimited, ok, tt.wantLimit, tt.wantUnlimited, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestStartResyncBackoff(t *testing.T) {
+	source := &stubSource{err: context.DeadlineExceeded}
+	svc := NewService(source, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	svc.StartResync(ctx, time.Hour)
+	<-ctx.Done()
+	elapsed := time.Since(start)
+
+	// With a 1s starting backoff, a ctx that lives ~1.2s should see at most
+	// two failed Load attempts (t=0 and t=~1s); it shouldn't busy-loop.
+	if elapsed < 1100*time.Millisecond {
+		t.Fatalf("ctx.Done() fired after %s, want roughly 1.2s", elapsed)
+	}
+	if loads := source.loads.Load(); loads > 2 {
+		t.Fatalf("Load called %d times within ~1.2s, want at most 2", loads)
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stellar/torifune/backend/internal/audit"
+	"github.com/stellar/torifune/backend/internal/entitlements"
+	"github.com/stellar/torifune/backend/internal/middleware"
+)
+
+// AuditHandler serves the admin-only audit log query endpoint.
+type AuditHandler struct {
+	lister       audit.Lister
+	entitlements *entitlements.Service
+}
+
+// NewAuditHandler creates a new AuditHandler. lister may be nil, in which
+// case the endpoint responds 503 to every caller that passes the
+// authentication and audit_read checks (audit logging is disabled or the
+// configured Auditor doesn't support querying).
+func NewAuditHandler(lister audit.Lister, entitlementsSvc *entitlements.Service) *AuditHandler {
+	return &AuditHandler{
+		lister:       lister,
+		entitlements: entitlementsSvc,
+	}
+}
+
+// auditListResponse is the JSON shape returned by List.
+type auditListResponse struct {
+	Events     []audit.Event `json:"events"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+// List handles GET /api/v1/audit. It is admin-only, gated by the
+// "audit_read" entitlement, and supports filtering by actor, action, date
+// range, and cursor-based pagination.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := middleware.GetUserID(ctx)
+	if userID == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	if h.entitlements == nil || !h.entitlements.HasFeature(userID, "audit_read") {
+		writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "audit_read entitlement is required")
+		return
+	}
+
+	if h.lister == nil {
+		writeErrorResponse(w, r, http.StatusServiceUnavailable, "audit_unavailable", "Audit logging is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := audit.ListFilter{
+		ActorID: query.Get("actor"),
+		Action:  query.Get("action"),
+		Cursor:  query.Get("cursor"),
+	}
+
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "since must be an RFC3339 timestamp")
+			return
+		}
+		filter.Since = since
+	}
+
+	if untilStr := query.Get("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "until must be an RFC3339 timestamp")
+			return
+		}
+		filter.Until = until
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			filter.Limit = limit
+		}
+	}
+
+	result, err := h.lister.List(ctx, filter)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to list audit events")
+		return
+	}
+
+	writeSuccessResponse(w, r, http.StatusOK, auditListResponse{
+		Events:     result.Events,
+		NextCursor: result.NextCursor,
+	})
+}
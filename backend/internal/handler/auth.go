@@ -3,45 +3,158 @@ package handler
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 
+	"github.com/stellar/torifune/backend/internal/audit"
+	"github.com/stellar/torifune/backend/internal/logging"
+	"github.com/stellar/torifune/backend/internal/middleware"
 	"github.com/stellar/torifune/backend/internal/model"
 	"github.com/stellar/torifune/backend/internal/repository"
 	"github.com/stellar/torifune/backend/internal/service/auth"
 )
 
+// authStateCookieName is the signed cookie Login sets when it generates
+// PKCE itself (see AuthHandler.Login), and CallbackRedirect reads back.
+const authStateCookieName = "torifune_auth_state"
+
+// authStateCookiePath scopes the auth-state cookie to the auth routes, so
+// it's never sent on unrelated requests.
+const authStateCookiePath = "/api/v1/auth"
+
 // AuthHandler handles authentication-related HTTP requests.
 type AuthHandler struct {
-	providers    map[auth.ProviderType]auth.OAuthProvider
-	jwtService   *auth.JWTService
-	userRepo     repository.UserRepository
+	providers  map[auth.ProviderType]auth.OAuthProvider
+	jwtService *auth.JWTService
+	userRepo   repository.UserRepository
+	auditor    audit.Auditor
+	// tokenStore persists OAuthToken per user/provider so it can later be
+	// refreshed (see auth.TokenRefresher). May be nil, in which case tokens
+	// are used for this callback only and never persisted.
+	tokenStore auth.TokenStore
+	// stateCodec signs/verifies the auth-state cookie Login sets when it
+	// generates PKCE itself (see CallbackRedirect).
+	stateCodec *auth.AuthStateCodec
+	// frontendURL is where CallbackRedirect sends the browser once login
+	// completes, if the login attempt didn't specify its own RedirectAfter.
+	frontendURL string
+	// allowedRedirectOrigins lists the origins a client-supplied
+	// RedirectAfter is allowed to target (see config.Config.CORSAllowedOrigins),
+	// so an attacker can't set redirect_after to an arbitrary origin and have
+	// a legitimate user's freshly-issued tokens delivered there.
+	allowedRedirectOrigins []string
+	// pkceRequired lists providers whose Login must reject a request with no
+	// client-supplied CodeChallenge instead of falling back to the
+	// server-generated-PKCE cookie flow (see config.Config.PKCERequiredProviders).
+	pkceRequired map[auth.ProviderType]bool
 }
 
-// NewAuthHandler creates a new AuthHandler.
+// NewAuthHandler creates a new AuthHandler. tokenStore may be nil if
+// persisting and refreshing OAuth tokens isn't configured for this
+// deployment (see config.Config.HasOAuthTokenStore). allowedRedirectOrigins
+// is the allow-list a client-supplied RedirectAfter is validated against
+// (typically the same origin list passed to middleware.CORSConfig).
+// pkceRequiredProviders lists provider names that must manage their own
+// PKCE code_challenge (see config.Config.PKCERequiredProviders).
 func NewAuthHandler(
 	providers map[auth.ProviderType]auth.OAuthProvider,
 	jwtService *auth.JWTService,
 	userRepo repository.UserRepository,
+	auditor audit.Auditor,
+	tokenStore auth.TokenStore,
+	stateCodec *auth.AuthStateCodec,
+	frontendURL string,
+	allowedRedirectOrigins []string,
+	pkceRequiredProviders []string,
 ) *AuthHandler {
+	pkceRequired := make(map[auth.ProviderType]bool, len(pkceRequiredProviders))
+	for _, name := range pkceRequiredProviders {
+		pkceRequired[auth.ProviderType(name)] = true
+	}
+
 	return &AuthHandler{
-		providers:  providers,
-		jwtService: jwtService,
-		userRepo:   userRepo,
+		providers:              providers,
+		jwtService:             jwtService,
+		userRepo:               userRepo,
+		auditor:                auditor,
+		tokenStore:             tokenStore,
+		stateCodec:             stateCodec,
+		frontendURL:            frontendURL,
+		allowedRedirectOrigins: allowedRedirectOrigins,
+		pkceRequired:           pkceRequired,
 	}
 }
 
+// redirectAllowed reports whether target's origin is in
+// h.allowedRedirectOrigins (or target is empty, since callers then fall back
+// to h.frontendURL).
+func (h *AuthHandler) redirectAllowed(target string) bool {
+	if target == "" {
+		return true
+	}
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return false
+	}
+	return middleware.OriginAllowed(parsed.Scheme+"://"+parsed.Host, h.allowedRedirectOrigins)
+}
+
+// logAuthEvent records an auth audit event if an auditor is configured.
+func (h *AuthHandler) logAuthEvent(r *http.Request, actorID, action, outcome string, metadata map[string]any) {
+	if h.auditor == nil {
+		return
+	}
+	if actorID == "" {
+		actorID = "anonymous"
+	}
+
+	_ = h.auditor.LogAuthEvent(r.Context(), audit.Event{
+		ActorID:   actorID,
+		ActorType: "user",
+		Action:    action,
+		Resource:  r.URL.Path,
+		IP:        middleware.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: chimiddleware.GetReqID(r.Context()),
+		Outcome:   outcome,
+		Metadata:  metadata,
+	})
+}
+
 // LoginRequest represents the request body for the login endpoint.
 type LoginRequest struct {
 	// CodeChallenge is the PKCE code challenge (S256 hash of code_verifier).
+	// If omitted, the server generates its own verifier/challenge pair and
+	// hands the verifier back as a signed HttpOnly cookie instead, so a
+	// browser SPA never holds it in JS-accessible storage; use the GET
+	// /callback/{provider} redirect flow to complete login in that case.
 	CodeChallenge string `json:"code_challenge"`
 	// State is an optional state parameter for CSRF protection.
 	// If not provided, the server will generate one.
 	State string `json:"state,omitempty"`
+	// Nonce is an optional OIDC nonce, echoed back in a provider's id_token
+	// and checked against CallbackRequest.Nonce (or the auth-state cookie,
+	// for the server-generated-PKCE flow). If not provided, the server will
+	// generate one.
+	Nonce string `json:"nonce,omitempty"`
+	// RedirectAfter is where to 302-redirect the browser once the
+	// server-generated-PKCE flow above completes. Only used when
+	// CodeChallenge is omitted, though Login rejects a value outside the
+	// allowed redirect origins either way. Defaults to the configured
+	// frontend URL if empty.
+	RedirectAfter string `json:"redirect_after,omitempty"`
 }
 
 // LoginResponse represents the response for the login endpoint.
@@ -50,6 +163,8 @@ type LoginResponse struct {
 	AuthURL string `json:"auth_url"`
 	// State is the state parameter to verify in the callback.
 	State string `json:"state"`
+	// Nonce is the OIDC nonce to echo back as CallbackRequest.Nonce.
+	Nonce string `json:"nonce"`
 }
 
 // CallbackRequest represents the request body for the callback endpoint.
@@ -60,6 +175,9 @@ type CallbackRequest struct {
 	State string `json:"state"`
 	// CodeVerifier is the PKCE code verifier (original random string).
 	CodeVerifier string `json:"code_verifier"`
+	// Nonce is the nonce from LoginResponse, checked against the id_token's
+	// "nonce" claim when the provider supports OIDC id_token verification.
+	Nonce string `json:"nonce,omitempty"`
 }
 
 // CallbackResponse represents the response for the callback endpoint.
@@ -74,6 +192,53 @@ type CallbackResponse struct {
 	User *model.User `json:"user"`
 }
 
+// DeviceAuthResponse represents the response for the device-auth endpoint
+// (RFC 8628 section 3.2).
+type DeviceAuthResponse struct {
+	// DeviceCode identifies this authorization session to the device-token
+	// endpoint.
+	DeviceCode string `json:"device_code"`
+	// UserCode is the short code the user enters at VerificationURI.
+	UserCode string `json:"user_code"`
+	// VerificationURI is where the user enters UserCode.
+	VerificationURI string `json:"verification_uri"`
+	// VerificationURIComplete embeds UserCode in VerificationURI so the
+	// user (or a QR code) can skip typing it in.
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	// ExpiresIn is how many seconds DeviceCode/UserCode remain valid.
+	ExpiresIn int `json:"expires_in"`
+	// Interval is the minimum number of seconds the client should wait
+	// between device-token polls.
+	Interval int `json:"interval"`
+}
+
+// DeviceTokenRequest represents the request body for the device-token
+// polling endpoint.
+type DeviceTokenRequest struct {
+	// DeviceCode is the code returned by the device-auth endpoint.
+	DeviceCode string `json:"device_code"`
+}
+
+// LogoutRequest represents the optional request body for the logout
+// endpoint.
+type LogoutRequest struct {
+	// RefreshToken, if provided, is revoked alongside the bearer access
+	// token so a logout invalidates the whole session rather than just
+	// the short-lived access token.
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// RevokeRequest represents the request body for the RFC 7009 revoke
+// endpoint.
+type RevokeRequest struct {
+	// Token is the access or refresh token to revoke.
+	Token string `json:"token"`
+	// TokenTypeHint is "access_token" or "refresh_token", per RFC 7009.
+	// Accepted but not required, since Token's type is determined from its
+	// own claims.
+	TokenTypeHint string `json:"token_type_hint,omitempty"`
+}
+
 // RefreshRequest represents the request body for the refresh endpoint.
 type RefreshRequest struct {
 	// RefreshToken is the JWT refresh token.
@@ -96,13 +261,13 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	providerName := chi.URLParam(r, "provider")
 	provider, ok := h.providers[auth.ProviderType(providerName)]
 	if !ok {
-		h.respondError(w, http.StatusBadRequest, "unsupported_provider", "OAuth provider not supported")
+		h.respondError(w, r, http.StatusBadRequest, "unsupported_provider", "OAuth provider not supported")
 		return
 	}
 
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
@@ -112,12 +277,104 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		state = uuid.New().String()
 	}
 
+	// Generate nonce if not provided.
+	nonce := req.Nonce
+	if nonce == "" {
+		nonce = uuid.New().String()
+	}
+
+	if !h.redirectAllowed(req.RedirectAfter) {
+		h.respondError(w, r, http.StatusBadRequest, "invalid_redirect", "redirect_after is not an allowed origin")
+		return
+	}
+
+	codeChallenge := req.CodeChallenge
+	if codeChallenge == "" && h.pkceRequired[auth.ProviderType(providerName)] {
+		h.respondError(w, r, http.StatusBadRequest, "pkce_required", "This provider requires a client-supplied code_challenge")
+		return
+	}
+	if codeChallenge == "" {
+		// No client-managed PKCE: generate the verifier/challenge ourselves
+		// and hand the verifier back to the browser as a signed cookie, so
+		// CallbackRedirect can recover it without the browser ever seeing it.
+		verifier, err := generateCodeVerifier()
+		if err != nil {
+			h.respondError(w, r, http.StatusInternalServerError, "login_failed", "Failed to start login")
+			return
+		}
+		codeChallenge = codeChallengeS256(verifier)
+
+		if err := h.setAuthStateCookie(w, auth.AuthState{
+			Provider:      providerName,
+			State:         state,
+			CodeVerifier:  verifier,
+			Nonce:         nonce,
+			RedirectAfter: req.RedirectAfter,
+		}); err != nil {
+			h.respondError(w, r, http.StatusInternalServerError, "login_failed", "Failed to start login")
+			return
+		}
+	}
+
 	// Get the authorization URL with PKCE support.
-	authURL := provider.GetAuthURL(state, req.CodeChallenge)
+	authURL := provider.GetAuthURL(state, codeChallenge, nonce)
 
 	h.respondJSON(w, http.StatusOK, LoginResponse{
 		AuthURL: authURL,
 		State:   state,
+		Nonce:   nonce,
+	})
+}
+
+// generateCodeVerifier returns a cryptographically random PKCE code
+// verifier: 32 random bytes, base64url-encoded to 43 characters, within
+// the 43-128 character range RFC 7636 section 4.1 requires.
+func generateCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate code verifier: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallengeS256 derives the PKCE S256 code challenge for verifier.
+func codeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// setAuthStateCookie signs state and sets it as a short-lived HttpOnly,
+// SameSite=Lax cookie scoped to the auth routes, so CallbackRedirect can
+// recover it without server-side session storage.
+func (h *AuthHandler) setAuthStateCookie(w http.ResponseWriter, state auth.AuthState) error {
+	signed, err := h.stateCodec.Encode(state)
+	if err != nil {
+		return fmt.Errorf("sign auth state: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     authStateCookieName,
+		Value:    signed,
+		Path:     authStateCookiePath,
+		MaxAge:   int(auth.GetAuthStateExpiry().Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// clearAuthStateCookie removes the auth-state cookie once its flow has
+// completed (successfully or not), so it can't be replayed.
+func clearAuthStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     authStateCookieName,
+		Value:    "",
+		Path:     authStateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
 	})
 }
 
@@ -128,49 +385,284 @@ func (h *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	providerType := auth.ProviderType(providerName)
 	provider, ok := h.providers[providerType]
 	if !ok {
-		h.respondError(w, http.StatusBadRequest, "unsupported_provider", "OAuth provider not supported")
+		h.respondError(w, r, http.StatusBadRequest, "unsupported_provider", "OAuth provider not supported")
 		return
 	}
 
 	var req CallbackRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Code == "" {
-		h.respondError(w, http.StatusBadRequest, "missing_code", "Authorization code is required")
+		h.respondError(w, r, http.StatusBadRequest, "missing_code", "Authorization code is required")
 		return
 	}
 
 	// Exchange the authorization code for OAuth tokens.
 	oauthToken, err := provider.ExchangeCode(r.Context(), req.Code, req.CodeVerifier)
 	if err != nil {
-		h.respondError(w, http.StatusUnauthorized, "token_exchange_failed", "Failed to exchange authorization code")
+		h.logAuthEvent(r, "", "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "token_exchange_failed"})
+		h.respondError(w, r, http.StatusUnauthorized, "token_exchange_failed", "Failed to exchange authorization code")
 		return
 	}
 
-	// Get user information from the OAuth provider.
-	userInfo, err := provider.GetUserInfo(r.Context(), oauthToken.AccessToken)
+	// Get user information from the OAuth provider, preferring a verified
+	// id_token over the userinfo endpoint when the provider supports it.
+	userInfo, err := getUserInfo(r.Context(), provider, oauthToken, req.Nonce)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, "user_info_failed", "Failed to retrieve user information")
+		h.logAuthEvent(r, "", "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "user_info_failed"})
+		h.respondError(w, r, http.StatusInternalServerError, "user_info_failed", "Failed to retrieve user information")
 		return
 	}
 
 	// Find or create the user in the database.
-	user, err := h.findOrCreateUser(r.Context(), providerType, userInfo)
+	user, err := h.findOrCreateUser(r.Context(), providerType, userInfo, oauthToken)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, "user_creation_failed", "Failed to create or update user")
+		h.logAuthEvent(r, "", "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "user_creation_failed"})
+		h.respondError(w, r, http.StatusInternalServerError, "user_creation_failed", "Failed to create or update user")
 		return
 	}
 
+	// Persist the OAuth token for later refreshing (e.g. by the Slack events
+	// handler) if a token store is configured and there's a refresh token to
+	// act on.
+	if h.tokenStore != nil && oauthToken.RefreshToken != "" {
+		if err := h.tokenStore.Save(r.Context(), user.ID, providerType, oauthToken); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to persist oauth token", "provider", providerName, "error", err)
+		}
+	}
+
 	// Generate JWT tokens.
-	accessToken, refreshToken, err := h.jwtService.GenerateTokens(user.ID)
+	accessToken, refreshToken, err := h.jwtService.GenerateTokens(r.Context(), user.ID)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, "token_generation_failed", "Failed to generate tokens")
+		h.logAuthEvent(r, user.ID, "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "token_generation_failed"})
+		h.respondError(w, r, http.StatusInternalServerError, "token_generation_failed", "Failed to generate tokens")
 		return
 	}
 
+	h.logAuthEvent(r, user.ID, "auth.login", audit.OutcomeSuccess, map[string]any{"provider": providerName})
+
+	h.respondJSON(w, http.StatusOK, CallbackResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(auth.GetAccessTokenExpiry().Seconds()),
+		User:         user,
+	})
+}
+
+// CallbackRedirect handles GET /api/v1/auth/callback/{provider}?code=...&state=...,
+// completing the server-generated-PKCE flow Login started when the caller
+// omitted code_challenge. It reads the code_verifier and expected state
+// back from the signed auth-state cookie instead of requiring the browser
+// to resubmit them, then 302-redirects to RedirectAfter (or FrontendURL)
+// with the issued tokens in the URL fragment, so they're never sent to a
+// server as part of the redirect.
+func (h *AuthHandler) CallbackRedirect(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+	providerType := auth.ProviderType(providerName)
+	provider, ok := h.providers[providerType]
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, "unsupported_provider", "OAuth provider not supported")
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+	if code == "" || state == "" {
+		h.respondError(w, r, http.StatusBadRequest, "missing_params", "code and state query parameters are required")
+		return
+	}
+
+	cookie, err := r.Cookie(authStateCookieName)
+	if err != nil {
+		h.respondError(w, r, http.StatusBadRequest, "missing_auth_state", "Login was not started from this browser")
+		return
+	}
+
+	authState, err := h.stateCodec.Decode(cookie.Value)
+	if err != nil || authState.Provider != providerName || authState.State != state {
+		clearAuthStateCookie(w)
+		h.respondError(w, r, http.StatusBadRequest, "invalid_auth_state", "Invalid or expired login attempt")
+		return
+	}
+	clearAuthStateCookie(w)
+
+	oauthToken, err := provider.ExchangeCode(r.Context(), code, authState.CodeVerifier)
+	if err != nil {
+		h.logAuthEvent(r, "", "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "token_exchange_failed"})
+		h.redirectWithError(w, r, authState.RedirectAfter, "token_exchange_failed")
+		return
+	}
+
+	userInfo, err := getUserInfo(r.Context(), provider, oauthToken, authState.Nonce)
+	if err != nil {
+		h.logAuthEvent(r, "", "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "user_info_failed"})
+		h.redirectWithError(w, r, authState.RedirectAfter, "user_info_failed")
+		return
+	}
+
+	user, err := h.findOrCreateUser(r.Context(), providerType, userInfo, oauthToken)
+	if err != nil {
+		h.logAuthEvent(r, "", "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "user_creation_failed"})
+		h.redirectWithError(w, r, authState.RedirectAfter, "user_creation_failed")
+		return
+	}
+
+	if h.tokenStore != nil && oauthToken.RefreshToken != "" {
+		if err := h.tokenStore.Save(r.Context(), user.ID, providerType, oauthToken); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to persist oauth token", "provider", providerName, "error", err)
+		}
+	}
+
+	accessToken, refreshToken, err := h.jwtService.GenerateTokens(r.Context(), user.ID)
+	if err != nil {
+		h.logAuthEvent(r, user.ID, "auth.login", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "token_generation_failed"})
+		h.redirectWithError(w, r, authState.RedirectAfter, "token_generation_failed")
+		return
+	}
+
+	h.logAuthEvent(r, user.ID, "auth.login", audit.OutcomeSuccess, map[string]any{"provider": providerName})
+
+	redirectURL := authState.RedirectAfter
+	if redirectURL == "" || !h.redirectAllowed(redirectURL) {
+		redirectURL = h.frontendURL
+	}
+	fragment := url.Values{
+		"access_token":  {accessToken},
+		"refresh_token": {refreshToken},
+		"expires_in":    {strconv.Itoa(int(auth.GetAccessTokenExpiry().Seconds()))},
+	}
+	http.Redirect(w, r, redirectURL+"#"+fragment.Encode(), http.StatusFound)
+}
+
+// redirectWithError 302-redirects to redirectAfter (or FrontendURL if
+// empty) with an error query parameter, for failures that happen after
+// the browser has already been sent here via a top-level navigation and
+// so can no longer be reported as a JSON error response.
+func (h *AuthHandler) redirectWithError(w http.ResponseWriter, r *http.Request, redirectAfter, reason string) {
+	target := redirectAfter
+	if target == "" || !h.redirectAllowed(target) {
+		target = h.frontendURL
+	}
+	q := url.Values{"error": {reason}}
+	http.Redirect(w, r, target+"?"+q.Encode(), http.StatusFound)
+}
+
+// DeviceAuth handles POST /api/v1/auth/device/{provider}.
+// It begins the OAuth 2.0 Device Authorization Grant (RFC 8628) flow for
+// clients that can't receive a redirect (CLIs, TVs).
+func (h *AuthHandler) DeviceAuth(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+	provider, ok := h.providers[auth.ProviderType(providerName)]
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, "unsupported_provider", "OAuth provider not supported")
+		return
+	}
+
+	deviceProvider, ok := provider.(auth.DeviceAuthProvider)
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, "unsupported_provider", "Provider does not support device authorization")
+		return
+	}
+
+	deviceAuth, err := deviceProvider.DeviceAuth(r.Context())
+	if err != nil {
+		h.logAuthEvent(r, "", "auth.device_auth", audit.OutcomeFailure, map[string]any{"provider": providerName})
+		h.respondError(w, r, http.StatusInternalServerError, "device_auth_failed", "Failed to start device authorization")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, DeviceAuthResponse{
+		DeviceCode:              deviceAuth.DeviceCode,
+		UserCode:                deviceAuth.UserCode,
+		VerificationURI:         deviceAuth.VerificationURI,
+		VerificationURIComplete: deviceAuth.VerificationURIComplete,
+		ExpiresIn:               deviceAuth.ExpiresIn,
+		Interval:                deviceAuth.Interval,
+	})
+}
+
+// DeviceToken handles POST /api/v1/auth/device/{provider}/token.
+// It polls the provider's token endpoint once for the given device code,
+// per RFC 8628 section 3.4; the caller is expected to call this repeatedly
+// at the interval from DeviceAuth until it gets a non-pending result.
+func (h *AuthHandler) DeviceToken(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+	providerType := auth.ProviderType(providerName)
+	provider, ok := h.providers[providerType]
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, "unsupported_provider", "OAuth provider not supported")
+		return
+	}
+
+	deviceProvider, ok := provider.(auth.DeviceAuthProvider)
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, "unsupported_provider", "Provider does not support device authorization")
+		return
+	}
+
+	var req DeviceTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.DeviceCode == "" {
+		h.respondError(w, r, http.StatusBadRequest, "missing_device_code", "Device code is required")
+		return
+	}
+
+	oauthToken, err := deviceProvider.PollDeviceToken(r.Context(), req.DeviceCode)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrAuthorizationPending):
+			h.respondError(w, r, http.StatusBadRequest, "authorization_pending", "Authorization is still pending")
+		case errors.Is(err, auth.ErrSlowDown):
+			h.respondError(w, r, http.StatusBadRequest, "slow_down", "Polling too frequently; increase the interval by 5 seconds")
+		case errors.Is(err, auth.ErrDeviceCodeExpired):
+			h.respondError(w, r, http.StatusBadRequest, "expired_token", "Device code has expired")
+		case errors.Is(err, auth.ErrDeviceAccessDenied):
+			h.respondError(w, r, http.StatusBadRequest, "access_denied", "User denied the authorization request")
+		default:
+			h.logAuthEvent(r, "", "auth.device_token", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "poll_failed"})
+			h.respondError(w, r, http.StatusInternalServerError, "device_token_failed", "Failed to poll device token")
+		}
+		return
+	}
+
+	// The device-authorization grant has no redirect to bind a nonce to, so
+	// there's nothing to check it against here; getUserInfo still prefers a
+	// verified id_token over the userinfo endpoint when one comes back.
+	userInfo, err := getUserInfo(r.Context(), provider, oauthToken, "")
+	if err != nil {
+		h.logAuthEvent(r, "", "auth.device_token", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "user_info_failed"})
+		h.respondError(w, r, http.StatusInternalServerError, "user_info_failed", "Failed to retrieve user information")
+		return
+	}
+
+	user, err := h.findOrCreateUser(r.Context(), providerType, userInfo, oauthToken)
+	if err != nil {
+		h.logAuthEvent(r, "", "auth.device_token", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "user_creation_failed"})
+		h.respondError(w, r, http.StatusInternalServerError, "user_creation_failed", "Failed to create or update user")
+		return
+	}
+
+	if h.tokenStore != nil && oauthToken.RefreshToken != "" {
+		if err := h.tokenStore.Save(r.Context(), user.ID, providerType, oauthToken); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to persist oauth token", "provider", providerName, "error", err)
+		}
+	}
+
+	accessToken, refreshToken, err := h.jwtService.GenerateTokens(r.Context(), user.ID)
+	if err != nil {
+		h.logAuthEvent(r, user.ID, "auth.device_token", audit.OutcomeFailure, map[string]any{"provider": providerName, "reason": "token_generation_failed"})
+		h.respondError(w, r, http.StatusInternalServerError, "token_generation_failed", "Failed to generate tokens")
+		return
+	}
+
+	h.logAuthEvent(r, user.ID, "auth.device_token", audit.OutcomeSuccess, map[string]any{"provider": providerName})
+
 	h.respondJSON(w, http.StatusOK, CallbackResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -184,30 +676,48 @@ func (h *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	var req RefreshRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.RefreshToken == "" {
-		h.respondError(w, http.StatusBadRequest, "missing_token", "Refresh token is required")
+		h.respondError(w, r, http.StatusBadRequest, "missing_token", "Refresh token is required")
 		return
 	}
 
 	// Validate and refresh the tokens.
-	accessToken, refreshToken, err := h.jwtService.RefreshTokens(req.RefreshToken)
+	accessToken, refreshToken, err := h.jwtService.RefreshTokens(r.Context(), req.RefreshToken)
 	if err != nil {
 		if errors.Is(err, auth.ErrExpiredToken) {
-			h.respondError(w, http.StatusUnauthorized, "token_expired", "Refresh token has expired")
+			h.logAuthEvent(r, "", "auth.refresh", audit.OutcomeFailure, map[string]any{"reason": "token_expired"})
+			h.respondError(w, r, http.StatusUnauthorized, "token_expired", "Refresh token has expired")
+			return
+		}
+		if errors.Is(err, auth.ErrRevokedToken) {
+			h.logAuthEvent(r, "", "auth.refresh", audit.OutcomeFailure, map[string]any{"reason": "token_revoked"})
+			h.respondError(w, r, http.StatusUnauthorized, "token_revoked", "Refresh token has been revoked")
+			return
+		}
+		if errors.Is(err, auth.ErrRefreshTokenReused) {
+			// The whole family is already revoked by RefreshTokens; surface
+			// a distinct reason so the client knows to force a fresh login
+			// rather than just retrying the refresh.
+			h.logAuthEvent(r, "", "auth.refresh", audit.OutcomeFailure, map[string]any{"reason": "refresh_token_reused"})
+			h.respondError(w, r, http.StatusUnauthorized, "refresh_token_reused", "Refresh token has already been used; please log in again")
 			return
 		}
 		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrInvalidTokenType) {
-			h.respondError(w, http.StatusUnauthorized, "invalid_token", "Invalid refresh token")
+			h.logAuthEvent(r, "", "auth.refresh", audit.OutcomeFailure, map[string]any{"reason": "invalid_token"})
+			h.respondError(w, r, http.StatusUnauthorized, "invalid_token", "Invalid refresh token")
 			return
 		}
-		h.respondError(w, http.StatusInternalServerError, "refresh_failed", "Failed to refresh token")
+		h.logAuthEvent(r, "", "auth.refresh", audit.OutcomeFailure, map[string]any{"reason": "refresh_failed"})
+		h.respondError(w, r, http.StatusInternalServerError, "refresh_failed", "Failed to refresh token")
 		return
 	}
 
+	h.logAuthEvent(r, "", "auth.refresh", audit.OutcomeSuccess, nil)
+
 	h.respondJSON(w, http.StatusOK, RefreshResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -216,14 +726,77 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 }
 
 // Logout handles POST /api/v1/auth/logout.
-// Currently, this is a no-op since we use stateless JWT tokens.
-// In a production system, you might want to blacklist the token.
+// It revokes the bearer access token and, if provided in the body, the
+// paired refresh token, so both are rejected for the remainder of their
+// natural lifetime instead of staying usable until they expire.
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// For stateless JWT, logout is handled client-side by discarding the token.
-	// A production implementation might add the token to a blacklist.
-	h.respondJSON(w, http.StatusOK, model.SuccessResponse{
-		Success: true,
-	})
+	var req LogoutRequest
+	_ = json.NewDecoder(r.Body).Decode(&req) // body is optional; ignore decode errors
+
+	if accessToken, ok := bearerToken(r); ok {
+		if err := h.jwtService.RevokeToken(r.Context(), accessToken); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to revoke access token", "error", err)
+		}
+	}
+	if req.RefreshToken != "" {
+		if err := h.jwtService.RevokeToken(r.Context(), req.RefreshToken); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to revoke refresh token", "error", err)
+		}
+	}
+
+	h.logAuthEvent(r, middleware.GetUserID(r.Context()), "auth.logout", audit.OutcomeSuccess, nil)
+
+	h.respondJSON(w, http.StatusOK, model.NewEnvelope(r, nil))
+}
+
+// Revoke handles POST /api/v1/auth/revoke, following RFC 7009: a client
+// presents a token it holds (access or refresh) and the server invalidates
+// it. token_type_hint is accepted for RFC compliance but isn't needed here
+// since RevokeToken handles either token type identically.
+func (h *AuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	var req RevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.Token == "" {
+		h.respondError(w, r, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	if err := h.jwtService.RevokeToken(r.Context(), req.Token); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to revoke token", "error", err)
+	}
+
+	// RFC 7009 section 2.2: respond 200 regardless of whether the token was
+	// valid, already revoked, or unrecognized, so callers can't use this
+	// endpoint to probe token validity.
+	h.logAuthEvent(r, "", "auth.revoke", audit.OutcomeSuccess, nil)
+	h.respondJSON(w, http.StatusOK, model.NewEnvelope(r, nil))
+}
+
+// bearerToken extracts the token from a well-formed "Authorization: Bearer
+// <token>" header, if present.
+func bearerToken(r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", false
+	}
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// getUserInfo retrieves the OAuth user's profile, preferring a provider's
+// verified id_token (via OIDCUserInfoProvider) over its extra
+// userinfo-endpoint round trip when the provider supports it.
+func getUserInfo(ctx context.Context, provider auth.OAuthProvider, oauthToken *auth.OAuthToken, expectedNonce string) (*auth.UserInfo, error) {
+	if oidcProvider, ok := provider.(auth.OIDCUserInfoProvider); ok {
+		return oidcProvider.GetUserInfoFromToken(ctx, oauthToken, expectedNonce)
+	}
+	return provider.GetUserInfo(ctx, oauthToken.AccessToken)
 }
 
 // findOrCreateUser looks up a user by provider ID, or creates a new user if not found.
@@ -231,6 +804,7 @@ func (h *AuthHandler) findOrCreateUser(
 	ctx context.Context,
 	providerType auth.ProviderType,
 	userInfo *auth.UserInfo,
+	oauthToken *auth.OAuthToken,
 ) (*model.User, error) {
 	// Try to find existing user by provider ID.
 	user, err := h.userRepo.GetByProviderID(ctx, string(providerType), userInfo.ID)
@@ -239,6 +813,7 @@ func (h *AuthHandler) findOrCreateUser(
 		user.Email = userInfo.Email
 		user.Name = userInfo.Name
 		user.Picture = userInfo.Picture
+		applySlackTokens(user, providerType, userInfo, oauthToken)
 		if err := h.userRepo.Update(ctx, user); err != nil {
 			return nil, err
 		}
@@ -260,6 +835,7 @@ func (h *AuthHandler) findOrCreateUser(
 		ProviderID: userInfo.ID,
 		Tier:       "free",
 	}
+	applySlackTokens(user, providerType, userInfo, oauthToken)
 
 	if err := h.userRepo.Create(ctx, user); err != nil {
 		return nil, err
@@ -268,6 +844,19 @@ func (h *AuthHandler) findOrCreateUser(
 	return user, nil
 }
 
+// applySlackTokens persists the workspace ID and bot/user tokens Slack
+// returns during its OAuth flow, so later webhook-driven processing (see
+// SlackEventsHandler) can act on the workspace without another OAuth round
+// trip. It's a no-op for every other provider.
+func applySlackTokens(user *model.User, providerType auth.ProviderType, userInfo *auth.UserInfo, oauthToken *auth.OAuthToken) {
+	if providerType != auth.ProviderSlack {
+		return
+	}
+	user.WorkspaceID = userInfo.WorkspaceID
+	user.SlackBotAccessToken = oauthToken.BotAccessToken
+	user.SlackUserAccessToken = oauthToken.AccessToken
+}
+
 // respondJSON writes a JSON response with the given status code.
 func (h *AuthHandler) respondJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -275,12 +864,10 @@ func (h *AuthHandler) respondJSON(w http.ResponseWriter, status int, data any) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// respondError writes a JSON error response with the given status code.
-func (h *AuthHandler) respondError(w http.ResponseWriter, status int, errorCode, message string) {
-	h.respondJSON(w, status, model.ErrorResponse{
-		Error:   errorCode,
-		Message: message,
-	})
+// respondError writes an RFC 7807 problem+json error response with the
+// given status code.
+func (h *AuthHandler) respondError(w http.ResponseWriter, r *http.Request, status int, errorCode, message string) {
+	model.WriteProblem(w, r, model.NewProblem(errorCode, status, message))
 }
 
 // RegisterRoutes registers the authentication routes on the given router.
@@ -288,7 +875,11 @@ func (h *AuthHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/v1/auth", func(r chi.Router) {
 		r.Post("/login/{provider}", h.Login)
 		r.Post("/callback/{provider}", h.Callback)
+		r.Get("/callback/{provider}", h.CallbackRedirect)
+		r.Post("/device/{provider}", h.DeviceAuth)
+		r.Post("/device/{provider}/token", h.DeviceToken)
 		r.Post("/refresh", h.Refresh)
 		r.Post("/logout", h.Logout)
+		r.Post("/revoke", h.Revoke)
 	})
 }
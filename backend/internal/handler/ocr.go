@@ -1,26 +1,180 @@
 package handler
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"cloud.google.com/go/storage"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"github.com/stellar/torifune/backend/internal/audit"
+	"github.com/stellar/torifune/backend/internal/entitlements"
+	"github.com/stellar/torifune/backend/internal/logging"
 	"github.com/stellar/torifune/backend/internal/middleware"
 	"github.com/stellar/torifune/backend/internal/model"
+	"github.com/stellar/torifune/backend/internal/ocr"
 	"github.com/stellar/torifune/backend/internal/repository"
+	"github.com/stellar/torifune/backend/internal/usage"
 )
 
+// asyncItemThreshold is the batch item count above which BatchProcess routes
+// the request through the async job queue instead of processing inline.
+const asyncItemThreshold = 10
+
+// maxBatchSize is the largest batch BatchProcess accepts in a single request.
+const maxBatchSize = 100
+
+// AsyncConfig bundles the dependencies OCRHandler needs to route large
+// batches through the async job queue (staging in GCS, then dispatching to
+// a Worker) instead of blocking the request. Leave the zero value to disable
+// async batching entirely; BatchProcess then always processes inline.
+type AsyncConfig struct {
+	GCSClient *storage.Client
+	GCSBucket string
+	JobStore  ocr.Store
+	Queue     ocr.Queue
+}
+
+// enabled reports whether async batching is fully configured.
+func (a AsyncConfig) enabled() bool {
+	return a.GCSClient != nil && a.JobStore != nil && a.Queue != nil
+}
+
 // OCRHandler handles OCR-related HTTP requests.
 type OCRHandler struct {
 	usageRepo     repository.UsageRepository
+	users         repository.UserRepository
 	freeTierLimit int
+	entitlements  *entitlements.Service
+	auditor       audit.Auditor
+	processor     ocr.Processor
+	async         AsyncConfig
+	// policy, if set, supersedes freeTierLimit/entitlements for quota
+	// enforcement: it evaluates per-user daily, per-org monthly, and
+	// per-org concurrent job limits together, and bills overage on tiers
+	// that allow it instead of rejecting. Nil falls back to the legacy
+	// single-limit-per-entity behavior.
+	policy *usage.PolicyEngine
 }
 
-// NewOCRHandler creates a new OCRHandler instance.
-func NewOCRHandler(usageRepo repository.UsageRepository, freeTierLimit int) *OCRHandler {
+// NewOCRHandler creates a new OCRHandler instance. policyEngine may be nil,
+// in which case quota is enforced by the legacy single FreeLimit/entitlements
+// override per entity.
+func NewOCRHandler(usageRepo repository.UsageRepository, users repository.UserRepository, freeTierLimit int, entitlementsSvc *entitlements.Service, auditor audit.Auditor, processor ocr.Processor, async AsyncConfig, policyEngine *usage.PolicyEngine) *OCRHandler {
 	return &OCRHandler{
 		usageRepo:     usageRepo,
+		users:         users,
 		freeTierLimit: freeTierLimit,
+		entitlements:  entitlementsSvc,
+		auditor:       auditor,
+		processor:     processor,
+		async:         async,
+		policy:        policyEngine,
+	}
+}
+
+// callerOrganizationID resolves the organization the authenticated caller
+// belongs to, so a client-supplied SchemaID can be checked against it
+// instead of trusted outright (see ocr.Input.OrganizationID).
+func (h *OCRHandler) callerOrganizationID(ctx context.Context, userID string) (string, error) {
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return user.OrganizationID, nil
+}
+
+// logOCREvent records an OCR audit event if an auditor is configured.
+func (h *OCRHandler) logOCREvent(r *http.Request, actorID, action, outcome string, metadata map[string]any) {
+	if h.auditor == nil {
+		return
+	}
+
+	_ = h.auditor.LogOCREvent(r.Context(), audit.Event{
+		ActorID:   actorID,
+		ActorType: "user",
+		Action:    action,
+		Resource:  r.URL.Path,
+		IP:        middleware.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: chimiddleware.GetReqID(r.Context()),
+		Outcome:   outcome,
+		Metadata:  metadata,
+	})
+}
+
+// effectiveLimit returns the quota limit that applies to entityID, consulting
+// entitlements before falling back to the hard-coded free-tier default.
+// unlimited is true when the entity has an active unlimited-OCR grant.
+func (h *OCRHandler) effectiveLimit(entityID string) (limit int, unlimited bool) {
+	if h.entitlements != nil {
+		if override, isUnlimited, ok := h.entitlements.QuotaOverride(entityID); ok {
+			if isUnlimited {
+				return 0, true
+			}
+			return override, false
+		}
+	}
+	return h.freeTierLimit, false
+}
+
+// resolveEntity determines which entity usage is tracked against: the
+// organization, if the caller specified one, otherwise the calling user.
+func resolveEntity(userID, organizationID string) (entityID, entityType string) {
+	if organizationID != "" {
+		return organizationID, "organization"
+	}
+	return userID, "user"
+}
+
+// reserveQuota reserves count units of quota ahead of processing, either via
+// h.policy (per-user daily, per-org monthly, and concurrent-job limits,
+// billing overage where the tier allows it) or, if that's not configured,
+// the legacy single FreeLimit/entitlements override per entity. reason and
+// remaining are set when ok is false, for building the error message
+// returned to the caller.
+func (h *OCRHandler) reserveQuota(ctx context.Context, entityID, entityType, userID string, count int) (ok bool, reason string, remaining int, err error) {
+	if h.policy != nil {
+		decision, err := h.policy.Evaluate(ctx, entityID, entityType, userID, count)
+		if err != nil {
+			return false, "", 0, err
+		}
+		return decision.Allowed, decision.Reason, decision.Remaining, nil
+	}
+
+	limit, unlimited := h.effectiveLimit(entityID)
+	reserved, remaining, err := h.usageRepo.ReserveQuota(ctx, entityID, entityType, count, limit, unlimited)
+	if err != nil {
+		return false, "", 0, err
+	}
+	if !reserved {
+		return false, "monthly_org_limit", remaining, nil
+	}
+	return true, "", 0, nil
+}
+
+// quotaExceededMessage builds the client-facing message for a reserveQuota
+// rejection.
+func quotaExceededMessage(reason string, remaining int) string {
+	switch reason {
+	case "daily_user_limit":
+		return "Daily usage limit exceeded for this user. Please try again tomorrow or upgrade your plan."
+	case "concurrent_job_limit":
+		return "Too many batch jobs are already queued or processing for this account. Please wait for one to finish."
+	default:
+		if remaining > 0 {
+			return "Batch size exceeds remaining monthly quota. You have " + strconv.Itoa(remaining) + " images remaining this month."
+		}
+		return "Monthly usage limit exceeded. Please upgrade your plan."
 	}
 }
 
@@ -32,6 +186,10 @@ type ProcessRequest struct {
 	MimeType string `json:"mimeType"`
 	// OrganizationID is optional; if provided, usage is tracked at org level.
 	OrganizationID string `json:"organizationId,omitempty"`
+	// SchemaID is optional; if provided, processing routes through the named
+	// model.ExtractionSchema instead of the deployment's default processor,
+	// and Fields is populated from its declared fields.
+	SchemaID string `json:"schemaId,omitempty"`
 }
 
 // ProcessResponse represents the response from OCR processing.
@@ -52,7 +210,8 @@ type BatchProcessRequest struct {
 	OrganizationID string `json:"organizationId,omitempty"`
 }
 
-// BatchProcessResponse represents the response from batch OCR processing.
+// BatchProcessResponse represents the response from synchronous batch OCR
+// processing.
 type BatchProcessResponse struct {
 	// Results contains the OCR results for each item.
 	Results []ProcessResponse `json:"results"`
@@ -60,6 +219,35 @@ type BatchProcessResponse struct {
 	FailedIndices []int `json:"failedIndices,omitempty"`
 }
 
+// BatchJobResponse is returned instead of BatchProcessResponse when a batch
+// is large enough to be routed through the async job queue.
+type BatchJobResponse struct {
+	JobID  string        `json:"jobId"`
+	Status ocr.JobStatus `json:"status"`
+}
+
+// JobResponse is the JSON shape returned for a single job, both from GetJob
+// and as each event of StreamJob.
+type JobResponse struct {
+	JobID   string            `json:"jobId"`
+	Status  ocr.JobStatus     `json:"status"`
+	Results []ProcessResponse `json:"results,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+func newJobResponse(job *ocr.Job) JobResponse {
+	results := make([]ProcessResponse, len(job.Results))
+	for i, res := range job.Results {
+		results[i] = ProcessResponse{Text: res.Text, Confidence: res.Confidence, Fields: res.Fields}
+	}
+	return JobResponse{
+		JobID:   job.ID,
+		Status:  job.Status,
+		Results: results,
+		Error:   job.Error,
+	}
+}
+
 // Process handles POST /api/v1/ocr/process.
 // It processes a single image or PDF with OCR.
 func (h *OCRHandler) Process(w http.ResponseWriter, r *http.Request) {
@@ -68,187 +256,398 @@ func (h *OCRHandler) Process(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from JWT claims
 	userID := middleware.GetUserID(ctx)
 	if userID == "" {
-		writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
 		return
 	}
 
 	// Parse request body
 	var req ProcessRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate request
 	if req.ImageData == "" {
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "imageData is required")
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "imageData is required")
 		return
 	}
 	if req.MimeType == "" {
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "mimeType is required")
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "mimeType is required")
 		return
 	}
 
-	// Determine entity for usage tracking (organization or user)
-	entityID := userID
-	entityType := "user"
-	if req.OrganizationID != "" {
-		entityID = req.OrganizationID
-		entityType = "organization"
-	}
+	entityID, entityType := resolveEntity(userID, req.OrganizationID)
 
-	// Check usage limits
-	usage, err := h.usageRepo.GetCurrentMonth(ctx, entityID, entityType)
+	organizationID, err := h.callerOrganizationID(ctx, userID)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to check usage")
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to resolve caller's organization")
 		return
 	}
 
-	if usage.ProcessedCount >= usage.FreeLimit {
-		writeErrorResponse(w, http.StatusPaymentRequired, "usage_limit_exceeded",
-			"Monthly usage limit exceeded. Please upgrade your plan.")
+	// Reserve quota atomically before dispatching, rather than checking then
+	// incrementing after the fact, so concurrent requests can't both squeeze
+	// past the limit in the gap between the two.
+	ok, reason, remaining, err := h.reserveQuota(ctx, entityID, entityType, userID, 1)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to check usage")
+		return
+	}
+	if !ok {
+		h.logOCREvent(r, userID, "ocr.process", audit.OutcomeDenied, map[string]any{"reason": reason, "entityId": entityID})
+		writeErrorResponse(w, r, http.StatusPaymentRequired, "usage_limit_exceeded", quotaExceededMessage(reason, remaining))
 		return
 	}
 
-	// TODO: Perform actual OCR processing using Document AI
-	// For now, return a placeholder response
-	response := ProcessResponse{
-		Text:       "OCR processing placeholder - implement Document AI integration",
-		Confidence: 0.0,
-		Fields:     make(map[string]any),
+	data, err := base64.StdEncoding.DecodeString(req.ImageData)
+	if err != nil {
+		h.releaseQuota(ctx, entityID, entityType, userID, 1)
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "imageData must be valid base64")
+		return
 	}
 
-	// Increment usage count after successful processing
-	if err := h.usageRepo.IncrementCount(ctx, entityID, entityType, 1); err != nil {
-		// Log error but don't fail the request since OCR was successful
-		// In production, consider using a background job for more reliable tracking
+	result, err := h.processor.Process(ctx, ocr.Input{Data: data, MimeType: req.MimeType, SchemaID: req.SchemaID, OrganizationID: organizationID})
+	if err != nil {
+		h.releaseQuota(ctx, entityID, entityType, userID, 1)
+		if errors.Is(err, ocr.ErrSchemaForbidden) {
+			h.logOCREvent(r, userID, "ocr.process", audit.OutcomeDenied, map[string]any{"entityId": entityID, "reason": "schema_forbidden"})
+			writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "Schema belongs to another organization")
+			return
+		}
+		h.logOCREvent(r, userID, "ocr.process", audit.OutcomeFailure, map[string]any{"entityId": entityID, "error": err.Error()})
+		writeErrorResponse(w, r, http.StatusBadGateway, "ocr_processing_failed", "Failed to process document")
+		return
 	}
 
-	writeSuccessResponse(w, http.StatusOK, response)
+	h.logOCREvent(r, userID, "ocr.process", audit.OutcomeSuccess, map[string]any{"entityId": entityID})
+
+	writeSuccessResponse(w, r, http.StatusOK, ProcessResponse{
+		Text:       result.Text,
+		Confidence: result.Confidence,
+		Fields:     result.Fields,
+	})
 }
 
-// BatchProcess handles POST /api/v1/ocr/batch.
-// It processes multiple images or PDFs with OCR.
+// BatchProcess handles POST /api/v1/ocr/batch. Small batches are processed
+// inline; batches larger than asyncItemThreshold (by item count) or
+// ocr.InlineSizeThreshold (by estimated decoded size) are instead staged in
+// GCS and routed through the async job queue, returning a jobId the caller
+// can poll or stream via GetJob/StreamJob.
 func (h *OCRHandler) BatchProcess(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Get user ID from JWT claims
 	userID := middleware.GetUserID(ctx)
 	if userID == "" {
-		writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
 		return
 	}
 
 	// Parse request body
 	body, err := io.ReadAll(io.LimitReader(r.Body, 100*1024*1024)) // 100MB limit
 	if err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "Failed to read request body")
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "Failed to read request body")
 		return
 	}
 	defer r.Body.Close()
 
 	var req BatchProcessRequest
 	if err := json.Unmarshal(body, &req); err != nil {
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	// Validate request
 	if len(req.Items) == 0 {
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "At least one item is required")
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "At least one item is required")
 		return
 	}
-
-	const maxBatchSize = 100
 	if len(req.Items) > maxBatchSize {
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request",
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request",
 			"Batch size exceeds maximum of 100 items")
 		return
 	}
 
-	// Determine entity for usage tracking (organization or user)
-	entityID := userID
-	entityType := "user"
-	if req.OrganizationID != "" {
-		entityID = req.OrganizationID
-		entityType = "organization"
+	entityID, entityType := resolveEntity(userID, req.OrganizationID)
+	itemCount := len(req.Items)
+
+	organizationID, err := h.callerOrganizationID(ctx, userID)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to resolve caller's organization")
+		return
 	}
 
-	// Check usage limits
-	usage, err := h.usageRepo.GetCurrentMonth(ctx, entityID, entityType)
+	// Reserve quota for the whole batch up front; unused reservations are
+	// released below once we know which items actually failed.
+	reserved, reason, remaining, err := h.reserveQuota(ctx, entityID, entityType, userID, itemCount)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to check usage")
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to check usage")
+		return
+	}
+	if !reserved {
+		h.logOCREvent(r, userID, "ocr.batch", audit.OutcomeDenied, map[string]any{"reason": reason, "entityId": entityID})
+		writeErrorResponse(w, r, http.StatusPaymentRequired, "usage_limit_exceeded", quotaExceededMessage(reason, remaining))
 		return
 	}
 
-	itemCount := len(req.Items)
-	if usage.ProcessedCount+itemCount > usage.FreeLimit {
-		remaining := usage.FreeLimit - usage.ProcessedCount
-		if remaining <= 0 {
-			writeErrorResponse(w, http.StatusPaymentRequired, "usage_limit_exceeded",
-				"Monthly usage limit exceeded. Please upgrade your plan.")
-		} else {
-			writeErrorResponse(w, http.StatusPaymentRequired, "usage_limit_exceeded",
-				"Batch size exceeds remaining monthly quota. "+
-					"You have "+string(rune(remaining))+" images remaining this month.")
-		}
+	if h.useAsync(req.Items) {
+		h.dispatchAsync(w, r, userID, entityID, entityType, organizationID, req.Items)
 		return
 	}
 
-	// TODO: Perform actual batch OCR processing using Document AI
-	// For now, return placeholder responses
 	results := make([]ProcessResponse, len(req.Items))
 	var failedIndices []int
 
-	for i := range req.Items {
-		// Validate each item
-		if req.Items[i].ImageData == "" || req.Items[i].MimeType == "" {
+	for i, item := range req.Items {
+		if item.ImageData == "" || item.MimeType == "" {
 			failedIndices = append(failedIndices, i)
 			continue
 		}
 
-		results[i] = ProcessResponse{
-			Text:       "OCR processing placeholder - implement Document AI integration",
-			Confidence: 0.0,
-			Fields:     make(map[string]any),
+		data, err := base64.StdEncoding.DecodeString(item.ImageData)
+		if err != nil {
+			failedIndices = append(failedIndices, i)
+			continue
 		}
+
+		result, err := h.processor.Process(ctx, ocr.Input{Data: data, MimeType: item.MimeType, SchemaID: item.SchemaID, OrganizationID: organizationID})
+		if err != nil {
+			logging.FromContext(ctx).Error("ocr: batch item processing failed", "error", err, "entityId", entityID, "index", i)
+			failedIndices = append(failedIndices, i)
+			continue
+		}
+
+		results[i] = ProcessResponse{Text: result.Text, Confidence: result.Confidence, Fields: result.Fields}
+	}
+
+	// The batch was reserved for itemCount units; release whatever wasn't
+	// actually used.
+	if failed := len(failedIndices); failed > 0 {
+		h.releaseQuota(ctx, entityID, entityType, userID, failed)
 	}
 
-	// Calculate successful count
 	successCount := len(req.Items) - len(failedIndices)
 
-	// Increment usage count for successfully processed items
-	if successCount > 0 {
-		if err := h.usageRepo.IncrementCount(ctx, entityID, entityType, successCount); err != nil {
-			// Log error but don't fail the request since OCR was successful
-		}
-	}
+	h.logOCREvent(r, userID, "ocr.batch", audit.OutcomeSuccess, map[string]any{
+		"entityId":     entityID,
+		"itemCount":    itemCount,
+		"successCount": successCount,
+	})
 
-	response := BatchProcessResponse{
+	writeSuccessResponse(w, r, http.StatusOK, BatchProcessResponse{
 		Results:       results,
 		FailedIndices: failedIndices,
+	})
+}
+
+// useAsync decides whether a batch should be routed through the async job
+// queue rather than processed inline within the request.
+func (h *OCRHandler) useAsync(items []ProcessRequest) bool {
+	if !h.async.enabled() {
+		return false
+	}
+	if len(items) > asyncItemThreshold {
+		return true
 	}
 
-	writeSuccessResponse(w, http.StatusOK, response)
+	var encodedLen int
+	for _, item := range items {
+		encodedLen += len(item.ImageData)
+	}
+	// Base64 inflates size by ~4/3; approximate the decoded size without
+	// actually decoding every item just to make this decision.
+	return encodedLen*3/4 > ocr.InlineSizeThreshold
 }
 
-// writeErrorResponse writes a JSON error response.
-func writeErrorResponse(w http.ResponseWriter, statusCode int, errorType, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(model.ErrorResponse{
-		Error:   errorType,
-		Message: message,
+// dispatchAsync stages each item in GCS, creates a Job, and enqueues it for
+// a Worker to pick up, responding with the jobId instead of blocking.
+func (h *OCRHandler) dispatchAsync(w http.ResponseWriter, r *http.Request, userID, entityID, entityType, organizationID string, items []ProcessRequest) {
+	ctx := r.Context()
+
+	jobItems := make([]ocr.JobItem, 0, len(items))
+	for i, item := range items {
+		if item.ImageData == "" || item.MimeType == "" {
+			h.releaseQuota(ctx, entityID, entityType, userID, len(items))
+			writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", fmt.Sprintf("items[%d] is missing imageData or mimeType", i))
+			return
+		}
+
+		data, err := base64.StdEncoding.DecodeString(item.ImageData)
+		if err != nil {
+			h.releaseQuota(ctx, entityID, entityType, userID, len(items))
+			writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", fmt.Sprintf("items[%d].imageData must be valid base64", i))
+			return
+		}
+
+		objectName := fmt.Sprintf("ocr-jobs/%s/%s/%d", entityID, uuid.New().String(), i)
+		if err := h.uploadToGCS(ctx, objectName, data, item.MimeType); err != nil {
+			logging.FromContext(ctx).Error("ocr: failed to stage batch item in GCS", "error", err, "entityId", entityID, "index", i)
+			h.releaseQuota(ctx, entityID, entityType, userID, len(items))
+			writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to stage batch for processing")
+			return
+		}
+
+		jobItems = append(jobItems, ocr.JobItem{
+			GCSInputURI:    fmt.Sprintf("gs://%s/%s", h.async.GCSBucket, objectName),
+			MimeType:       item.MimeType,
+			SchemaID:       item.SchemaID,
+			OrganizationID: organizationID,
+		})
+	}
+
+	job := &ocr.Job{
+		EntityID:   entityID,
+		EntityType: entityType,
+		Status:     ocr.JobStatusQueued,
+		Items:      jobItems,
+	}
+	if err := h.async.JobStore.Create(ctx, job); err != nil {
+		h.releaseQuota(ctx, entityID, entityType, userID, len(items))
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to create job")
+		return
+	}
+	if err := h.async.Queue.Enqueue(ctx, job.ID); err != nil {
+		h.releaseQuota(ctx, entityID, entityType, userID, len(items))
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to enqueue job")
+		return
+	}
+
+	h.logOCREvent(r, userID, "ocr.batch", audit.OutcomeSuccess, map[string]any{
+		"entityId":  entityID,
+		"itemCount": len(items),
+		"jobId":     job.ID,
+		"async":     true,
 	})
+
+	writeSuccessResponse(w, r, http.StatusAccepted, BatchJobResponse{JobID: job.ID, Status: job.Status})
+}
+
+// uploadToGCS writes data to the configured bucket under objectName.
+func (h *OCRHandler) uploadToGCS(ctx context.Context, objectName string, data []byte, mimeType string) error {
+	writer := h.async.GCSClient.Bucket(h.async.GCSBucket).Object(objectName).NewWriter(ctx)
+	writer.ContentType = mimeType
+
+	if _, err := writer.Write(data); err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("ocr: upload to gcs: %w", err)
+	}
+	return writer.Close()
 }
 
-// writeSuccessResponse writes a JSON success response.
-func writeSuccessResponse(w http.ResponseWriter, statusCode int, data any) {
+// releaseQuota rolls back a reservation, logging rather than failing the
+// request if the rollback itself errors.
+func (h *OCRHandler) releaseQuota(ctx context.Context, entityID, entityType, userID string, count int) {
+	if h.policy != nil {
+		h.policy.Release(ctx, entityID, entityType, userID, count)
+		return
+	}
+	if err := h.usageRepo.ReleaseQuota(ctx, entityID, entityType, count); err != nil {
+		logging.FromContext(ctx).Error("failed to release reserved quota", "error", err, "entityId", entityID, "count", count)
+	}
+}
+
+// jobIDParam extracts the {id} path parameter shared by GetJob and StreamJob.
+func jobIDParam(r *http.Request) string {
+	return chi.URLParam(r, "id")
+}
+
+// GetJob handles GET /api/v1/ocr/jobs/{id}, returning the current status
+// (and results, once available) of an async batch job.
+func (h *OCRHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if middleware.GetUserID(ctx) == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	if h.async.JobStore == nil {
+		writeErrorResponse(w, r, http.StatusServiceUnavailable, "async_ocr_unavailable", "Async OCR processing is not configured")
+		return
+	}
+
+	job, err := h.async.JobStore.Get(ctx, jobIDParam(r))
+	if err != nil {
+		if errors.Is(err, ocr.ErrJobNotFound) {
+			writeErrorResponse(w, r, http.StatusNotFound, "not_found", "Job not found")
+			return
+		}
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch job")
+		return
+	}
+
+	writeSuccessResponse(w, r, http.StatusOK, newJobResponse(job))
+}
+
+// jobPollInterval is how often StreamJob re-checks job status.
+const jobPollInterval = 2 * time.Second
+
+// StreamJob handles GET /api/v1/ocr/jobs/{id}/stream, a Server-Sent Events
+// endpoint that pushes job status updates until the job reaches a terminal
+// state or the client disconnects.
+func (h *OCRHandler) StreamJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if middleware.GetUserID(ctx) == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	if h.async.JobStore == nil {
+		writeErrorResponse(w, r, http.StatusServiceUnavailable, "async_ocr_unavailable", "Async OCR processing is not configured")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Streaming is not supported")
+		return
+	}
+
+	jobID := jobIDParam(r)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := h.async.JobStore.Get(ctx, jobID)
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+			flusher.Flush()
+			return
+		}
+
+		payload, _ := json.Marshal(newJobResponse(job))
+		fmt.Fprintf(w, "data: %s\n\n", payload)
+		flusher.Flush()
+
+		if job.Status == ocr.JobStatusSucceeded || job.Status == ocr.JobStatusFailed {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeErrorResponse writes an RFC 7807 problem+json error response.
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, errorType, message string) {
+	model.WriteProblem(w, r, model.NewProblem(errorType, statusCode, message))
+}
+
+// writeSuccessResponse writes a JSON success response, with a trace_id for
+// correlating with server logs (see model.NewEnvelope).
+func writeSuccessResponse(w http.ResponseWriter, r *http.Request, statusCode int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(model.SuccessResponse{
-		Success: true,
-		Data:    data,
-	})
+	json.NewEncoder(w).Encode(model.NewEnvelope(r, data))
 }
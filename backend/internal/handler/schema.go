@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stellar/torifune/backend/internal/middleware"
+	"github.com/stellar/torifune/backend/internal/model"
+	"github.com/stellar/torifune/backend/internal/repository"
+)
+
+// SchemaHandler serves CRUD endpoints for model.ExtractionSchema, letting
+// callers define invoice/receipt/ID-card templates for structured field
+// extraction without code changes.
+type SchemaHandler struct {
+	schemas repository.SchemaRepository
+	users   repository.UserRepository
+}
+
+// NewSchemaHandler creates a new SchemaHandler.
+func NewSchemaHandler(schemas repository.SchemaRepository, users repository.UserRepository) *SchemaHandler {
+	return &SchemaHandler{schemas: schemas, users: users}
+}
+
+// callerOrganizationID resolves the organization the authenticated caller
+// belongs to, so Get/Update/Delete/List/Create can scope access to schemas
+// owned by that organization instead of trusting an ID from the request.
+func (h *SchemaHandler) callerOrganizationID(ctx context.Context) (string, error) {
+	user, err := h.users.GetByID(ctx, middleware.GetUserID(ctx))
+	if err != nil {
+		return "", err
+	}
+	return user.OrganizationID, nil
+}
+
+// RegisterRoutes registers the extraction schema routes on the given router.
+func (h *SchemaHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/api/v1/schemas", func(r chi.Router) {
+		r.Post("/", h.Create)
+		r.Get("/", h.List)
+		r.Get("/{id}", h.Get)
+		r.Put("/{id}", h.Update)
+		r.Delete("/{id}", h.Delete)
+	})
+}
+
+// schemaRequest is the JSON shape accepted by Create and Update; it omits
+// ID/timestamps, which the server assigns. OrganizationID isn't accepted
+// here: Create records the caller's own organization rather than trusting
+// one supplied in the request body.
+type schemaRequest struct {
+	Name        string              `json:"name"`
+	ProcessorID string              `json:"processorId,omitempty"`
+	Fields      []model.SchemaField `json:"fields"`
+}
+
+// Create handles POST /api/v1/schemas.
+func (h *SchemaHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if middleware.GetUserID(ctx) == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	organizationID, err := h.callerOrganizationID(ctx)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to resolve caller's organization")
+		return
+	}
+	if organizationID == "" {
+		writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "Caller does not belong to an organization")
+		return
+	}
+
+	var req schemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Name == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "name is required")
+		return
+	}
+
+	schema := &model.ExtractionSchema{
+		OrganizationID: organizationID,
+		Name:           req.Name,
+		ProcessorID:    req.ProcessorID,
+		Fields:         req.Fields,
+	}
+	if err := h.schemas.Create(ctx, schema); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to create schema")
+		return
+	}
+
+	writeSuccessResponse(w, r, http.StatusCreated, schema)
+}
+
+// List handles GET /api/v1/schemas?organizationId=..., restricted to the
+// caller's own organization.
+func (h *SchemaHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if middleware.GetUserID(ctx) == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	organizationID := r.URL.Query().Get("organizationId")
+	if organizationID == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "organizationId is required")
+		return
+	}
+
+	callerOrganizationID, err := h.callerOrganizationID(ctx)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to resolve caller's organization")
+		return
+	}
+	if callerOrganizationID == "" || organizationID != callerOrganizationID {
+		writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "Cannot list schemas belonging to another organization")
+		return
+	}
+
+	schemas, err := h.schemas.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to list schemas")
+		return
+	}
+
+	writeSuccessResponse(w, r, http.StatusOK, schemas)
+}
+
+// Get handles GET /api/v1/schemas/{id}.
+func (h *SchemaHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if middleware.GetUserID(ctx) == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	schema, err := h.schemas.GetByID(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrSchemaNotFound) {
+			writeErrorResponse(w, r, http.StatusNotFound, "not_found", "Schema not found")
+			return
+		}
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch schema")
+		return
+	}
+
+	if !h.callerOwnsSchema(w, r, schema) {
+		return
+	}
+
+	writeSuccessResponse(w, r, http.StatusOK, schema)
+}
+
+// callerOwnsSchema reports whether the authenticated caller belongs to
+// schema's organization, writing the appropriate error response (and
+// returning false) if not or if the caller's organization can't be
+// resolved.
+func (h *SchemaHandler) callerOwnsSchema(w http.ResponseWriter, r *http.Request, schema *model.ExtractionSchema) bool {
+	organizationID, err := h.callerOrganizationID(r.Context())
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to resolve caller's organization")
+		return false
+	}
+	if organizationID == "" || schema.OrganizationID != organizationID {
+		writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "Schema belongs to another organization")
+		return false
+	}
+	return true
+}
+
+// Update handles PUT /api/v1/schemas/{id}.
+func (h *SchemaHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if middleware.GetUserID(ctx) == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	existing, err := h.schemas.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSchemaNotFound) {
+			writeErrorResponse(w, r, http.StatusNotFound, "not_found", "Schema not found")
+			return
+		}
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch schema")
+		return
+	}
+
+	if !h.callerOwnsSchema(w, r, existing) {
+		return
+	}
+
+	var req schemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Name == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "name is required")
+		return
+	}
+
+	existing.Name = req.Name
+	existing.ProcessorID = req.ProcessorID
+	existing.Fields = req.Fields
+
+	if err := h.schemas.Update(ctx, existing); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to update schema")
+		return
+	}
+
+	writeSuccessResponse(w, r, http.StatusOK, existing)
+}
+
+// Delete handles DELETE /api/v1/schemas/{id}.
+func (h *SchemaHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if middleware.GetUserID(ctx) == "" {
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	existing, err := h.schemas.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSchemaNotFound) {
+			writeErrorResponse(w, r, http.StatusNotFound, "not_found", "Schema not found")
+			return
+		}
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to fetch schema")
+		return
+	}
+
+	if !h.callerOwnsSchema(w, r, existing) {
+		return
+	}
+
+	if err := h.schemas.Delete(ctx, id); err != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to delete schema")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
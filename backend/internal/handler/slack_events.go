@@ -0,0 +1,402 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/stellar/torifune/backend/internal/audit"
+	"github.com/stellar/torifune/backend/internal/entitlements"
+	"github.com/stellar/torifune/backend/internal/logging"
+	"github.com/stellar/torifune/backend/internal/model"
+	"github.com/stellar/torifune/backend/internal/ocr"
+	"github.com/stellar/torifune/backend/internal/repository"
+	"github.com/stellar/torifune/backend/internal/service/auth"
+)
+
+// maxSlackEventSkew bounds how far a webhook's timestamp may drift from now
+// before its signature is rejected outright, defeating replay of a captured
+// request.
+const maxSlackEventSkew = 5 * time.Minute
+
+// slackOCRMimeTypes are the file types worth running OCR on. file_shared
+// fires for every upload, not just images/PDFs, so anything else is ignored.
+var slackOCRMimeTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// SlackEventsHandler handles Slack's Events API webhook. It verifies each
+// request's signature, answers the one-time url_verification handshake, and
+// dispatches file_shared events into the OCR pipeline using the workspace's
+// stored bot token.
+type SlackEventsHandler struct {
+	signingSecret string
+	userRepo      repository.UserRepository
+	usageRepo     repository.UsageRepository
+	freeTierLimit int
+	entitlements  *entitlements.Service
+	processor     ocr.Processor
+	auditor       audit.Auditor
+	httpClient    *http.Client
+	// refresher, if set, is consulted for a non-stale bot token ahead of the
+	// one stored on model.User, which goes stale once Slack's token rotation
+	// expires it (see auth.TokenRefresher). Nil when token persistence isn't
+	// configured for this deployment, in which case the stored token is used
+	// as-is for the lifetime of the process.
+	refresher *auth.TokenRefresher
+}
+
+// NewSlackEventsHandler creates a new SlackEventsHandler. refresher may be
+// nil if persisting and refreshing OAuth tokens isn't configured (see
+// config.Config.HasOAuthTokenStore).
+func NewSlackEventsHandler(
+	signingSecret string,
+	userRepo repository.UserRepository,
+	usageRepo repository.UsageRepository,
+	freeTierLimit int,
+	entitlementsSvc *entitlements.Service,
+	processor ocr.Processor,
+	auditor audit.Auditor,
+	refresher *auth.TokenRefresher,
+) *SlackEventsHandler {
+	return &SlackEventsHandler{
+		signingSecret: signingSecret,
+		userRepo:      userRepo,
+		usageRepo:     usageRepo,
+		freeTierLimit: freeTierLimit,
+		entitlements:  entitlementsSvc,
+		processor:     processor,
+		auditor:       auditor,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		refresher:     refresher,
+	}
+}
+
+// slackEventEnvelope is the outer JSON body Slack POSTs to the Events API,
+// covering both the url_verification handshake and event_callback deliveries.
+type slackEventEnvelope struct {
+	Type      string          `json:"type"`
+	Challenge string          `json:"challenge,omitempty"`
+	TeamID    string          `json:"team_id,omitempty"`
+	Event     json.RawMessage `json:"event,omitempty"`
+}
+
+// slackInnerEvent covers the fields used across the event types this handler
+// dispatches (currently file_shared).
+type slackInnerEvent struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+	UserID  string `json:"user_id,omitempty"`
+	FileID  string `json:"file_id,omitempty"`
+}
+
+// Handle serves POST /api/v1/slack/events.
+func (h *SlackEventsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	reqID := chimiddleware.GetReqID(r.Context())
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20)) // Slack event payloads are small JSON
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if !h.verifySignature(r, body) {
+		h.logSlackEvent(r.URL.Path, reqID, audit.OutcomeFailure, map[string]any{"reason": "invalid_signature"})
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var envelope slackEventEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if envelope.Type == "url_verification" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"challenge": envelope.Challenge})
+		return
+	}
+
+	// Acknowledge immediately: Slack retries a delivery that doesn't see a
+	// 200 within 3 seconds, well inside the time OCR processing can take.
+	w.WriteHeader(http.StatusOK)
+
+	if envelope.Type != "event_callback" {
+		return
+	}
+
+	var event slackInnerEvent
+	if err := json.Unmarshal(envelope.Event, &event); err != nil {
+		return
+	}
+	if event.Type != "file_shared" {
+		return
+	}
+
+	go h.processFileShared(envelope.TeamID, event, reqID)
+}
+
+// verifySignature validates Slack's X-Slack-Signature header: the v0 scheme
+// is HMAC-SHA256("v0:{timestamp}:{body}", signingSecret), hex-encoded and
+// prefixed "v0=", compared in constant time.
+func (h *SlackEventsHandler) verifySignature(r *http.Request, body []byte) bool {
+	if h.signingSecret == "" {
+		return false
+	}
+
+	timestampHeader := r.Header.Get("X-Slack-Request-Timestamp")
+	signatureHeader := r.Header.Get("X-Slack-Signature")
+	if timestampHeader == "" || signatureHeader == "" {
+		return false
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > maxSlackEventSkew || age < -maxSlackEventSkew {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.signingSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestampHeader, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signatureHeader))
+}
+
+// processFileShared downloads the shared file with the workspace's bot
+// token, runs it through the OCR pipeline, and posts the extracted text back
+// to the channel it was shared in. It runs after the webhook response has
+// already been sent, so errors are logged rather than returned anywhere.
+func (h *SlackEventsHandler) processFileShared(teamID string, event slackInnerEvent, reqID string) {
+	ctx := context.Background()
+
+	user, err := h.userRepo.GetByWorkspaceID(ctx, teamID)
+	if err != nil || user.SlackBotAccessToken == "" {
+		logging.FromContext(ctx).Error("slack: no bot token for workspace", "team_id", teamID, "error", err)
+		return
+	}
+	botToken := h.botToken(ctx, user)
+
+	mimetype, urlPrivate, err := h.fetchFileInfo(ctx, botToken, event.FileID)
+	if err != nil {
+		logging.FromContext(ctx).Error("slack: failed to fetch file info", "team_id", teamID, "file_id", event.FileID, "error", err)
+		return
+	}
+	if !slackOCRMimeTypes[mimetype] {
+		return
+	}
+
+	entityID, entityType := teamID, "organization"
+	limit, unlimited := h.effectiveLimitFor(entityID)
+
+	ok, _, err := h.usageRepo.ReserveQuota(ctx, entityID, entityType, 1, limit, unlimited)
+	if err != nil {
+		logging.FromContext(ctx).Error("slack: failed to reserve quota", "team_id", teamID, "error", err)
+		return
+	}
+	if !ok {
+		h.logSlackEvent(event.FileID, reqID, audit.OutcomeDenied, map[string]any{"reason": "usage_limit_exceeded", "teamId": teamID})
+		return
+	}
+
+	data, err := h.downloadFile(ctx, botToken, urlPrivate)
+	if err != nil {
+		h.releaseQuota(ctx, entityID, entityType)
+		logging.FromContext(ctx).Error("slack: failed to download file", "team_id", teamID, "file_id", event.FileID, "error", err)
+		return
+	}
+
+	result, err := h.processor.Process(ctx, ocr.Input{Data: data, MimeType: mimetype})
+	if err != nil {
+		h.releaseQuota(ctx, entityID, entityType)
+		h.logSlackEvent(event.FileID, reqID, audit.OutcomeFailure, map[string]any{"teamId": teamID, "error": err.Error()})
+		return
+	}
+
+	h.logSlackEvent(event.FileID, reqID, audit.OutcomeSuccess, map[string]any{"teamId": teamID, "fileId": event.FileID})
+
+	if err := h.postMessage(ctx, botToken, event.Channel, result.Text); err != nil {
+		logging.FromContext(ctx).Error("slack: failed to post OCR result", "team_id", teamID, "channel", event.Channel, "error", err)
+	}
+}
+
+// botToken returns the freshest bot token available for user: a refreshed
+// one from h.refresher if configured and it succeeds, falling back to the
+// token stored on the user record (set at OAuth callback time and, absent a
+// refresher, never updated again).
+func (h *SlackEventsHandler) botToken(ctx context.Context, user *model.User) string {
+	if h.refresher != nil {
+		if token, err := h.refresher.Get(ctx, user.ID, auth.ProviderSlack); err == nil && token.BotAccessToken != "" {
+			return token.BotAccessToken
+		}
+	}
+	return user.SlackBotAccessToken
+}
+
+// releaseQuota rolls back a reservation, logging rather than failing
+// (there's no request left to fail) if the rollback itself errors.
+func (h *SlackEventsHandler) releaseQuota(ctx context.Context, entityID, entityType string) {
+	if err := h.usageRepo.ReleaseQuota(ctx, entityID, entityType, 1); err != nil {
+		logging.FromContext(ctx).Error("slack: failed to release reserved quota", "error", err, "entityId", entityID)
+	}
+}
+
+// effectiveLimitFor mirrors OCRHandler.effectiveLimit: entitlements override
+// the hard-coded free-tier default when present.
+func (h *SlackEventsHandler) effectiveLimitFor(entityID string) (limit int, unlimited bool) {
+	if h.entitlements != nil {
+		if override, isUnlimited, ok := h.entitlements.QuotaOverride(entityID); ok {
+			if isUnlimited {
+				return 0, true
+			}
+			return override, false
+		}
+	}
+	return h.freeTierLimit, false
+}
+
+// slackFileInfoResponse is the subset of files.info's response used to
+// download and OCR the shared file.
+type slackFileInfoResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	File  struct {
+		Mimetype   string `json:"mimetype"`
+		URLPrivate string `json:"url_private"`
+	} `json:"file"`
+}
+
+// fetchFileInfo calls Slack's files.info API to resolve a file ID to its
+// download URL and MIME type.
+func (h *SlackEventsHandler) fetchFileInfo(ctx context.Context, botToken, fileID string) (mimetype, urlPrivate string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://slack.com/api/files.info?file="+fileID, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+botToken)
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	var info slackFileInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", "", err
+	}
+	if !info.OK {
+		return "", "", fmt.Errorf("slack API error: %s", info.Error)
+	}
+
+	return info.File.Mimetype, info.File.URLPrivate, nil
+}
+
+// downloadFile fetches a Slack file's raw bytes from its url_private,
+// authenticating with the bot token as Slack requires for private files.
+func (h *SlackEventsHandler) downloadFile(ctx context.Context, botToken, urlPrivate string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlPrivate, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+botToken)
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d downloading slack file", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// slackPostMessageRequest is the request body for chat.postMessage.
+type slackPostMessageRequest struct {
+	Channel string `json:"channel"`
+	Text    string `json:"text"`
+}
+
+// postMessage posts the OCR result back to the channel the file was shared
+// in via chat.postMessage.
+func (h *SlackEventsHandler) postMessage(ctx context.Context, botToken, channel, text string) error {
+	if text == "" {
+		text = "(no text detected)"
+	}
+
+	payload, err := json.Marshal(slackPostMessageRequest{Channel: channel, Text: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://slack.com/api/chat.postMessage", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+botToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return fmt.Errorf("slack API error: %s", result.Error)
+	}
+
+	return nil
+}
+
+// logSlackEvent records an OCR audit event for a Slack-driven OCR run.
+func (h *SlackEventsHandler) logSlackEvent(resource, reqID, outcome string, metadata map[string]any) {
+	if h.auditor == nil {
+		return
+	}
+
+	_ = h.auditor.LogOCREvent(context.Background(), audit.Event{
+		ActorType: "system",
+		ActorID:   "slack",
+		Action:    "ocr.slack",
+		Resource:  resource,
+		RequestID: reqID,
+		Outcome:   outcome,
+		Metadata:  metadata,
+	})
+}
+
+// RegisterRoutes registers the Slack Events API route on the given router.
+func (h *SlackEventsHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/api/v1/slack/events", h.Handle)
+}
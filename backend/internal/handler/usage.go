@@ -4,19 +4,22 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/stellar/torifune/backend/internal/entitlements"
 	"github.com/stellar/torifune/backend/internal/middleware"
 	"github.com/stellar/torifune/backend/internal/repository"
 )
 
 // UsageHandler handles usage-related HTTP requests.
 type UsageHandler struct {
-	usageRepo repository.UsageRepository
+	usageRepo    repository.UsageRepository
+	entitlements *entitlements.Service
 }
 
 // NewUsageHandler creates a new UsageHandler instance.
-func NewUsageHandler(usageRepo repository.UsageRepository) *UsageHandler {
+func NewUsageHandler(usageRepo repository.UsageRepository, entitlementsSvc *entitlements.Service) *UsageHandler {
 	return &UsageHandler{
-		usageRepo: usageRepo,
+		usageRepo:    usageRepo,
+		entitlements: entitlementsSvc,
 	}
 }
 
@@ -32,6 +35,8 @@ type CurrentUsageResponse struct {
 	Remaining int `json:"remaining"`
 	// UsagePercentage is the usage as a percentage (0-100).
 	UsagePercentage float64 `json:"usagePercentage"`
+	// Unlimited is true when an entitlement grants unlimited OCR usage for this entity.
+	Unlimited bool `json:"unlimited"`
 }
 
 // UsageHistoryResponse represents the response for usage history.
@@ -58,7 +63,7 @@ func (h *UsageHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from JWT claims
 	userID := middleware.GetUserID(ctx)
 	if userID == "" {
-		writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
 		return
 	}
 
@@ -73,10 +78,22 @@ func (h *UsageHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
 	// Get current month's usage
 	usage, err := h.usageRepo.GetCurrentMonth(ctx, entityID, entityType)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to get usage data")
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to get usage data")
 		return
 	}
 
+	// Paid entitlements can override the hard-coded free-tier limit, or grant
+	// unlimited usage outright.
+	unlimited := false
+	if h.entitlements != nil {
+		if limit, isUnlimited, ok := h.entitlements.QuotaOverride(entityID); ok {
+			unlimited = isUnlimited
+			if !isUnlimited {
+				usage.FreeLimit = limit
+			}
+		}
+	}
+
 	// Calculate remaining and percentage
 	remaining := max(usage.FreeLimit-usage.ProcessedCount, 0)
 
@@ -94,9 +111,10 @@ func (h *UsageHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
 		FreeLimit:       usage.FreeLimit,
 		Remaining:       remaining,
 		UsagePercentage: usagePercentage,
+		Unlimited:       unlimited,
 	}
 
-	writeSuccessResponse(w, http.StatusOK, response)
+	writeSuccessResponse(w, r, http.StatusOK, response)
 }
 
 // GetHistory handles GET /api/v1/usage/history.
@@ -107,7 +125,7 @@ func (h *UsageHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from JWT claims
 	userID := middleware.GetUserID(ctx)
 	if userID == "" {
-		writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "User not authenticated")
+		writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "User not authenticated")
 		return
 	}
 
@@ -128,7 +146,7 @@ func (h *UsageHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	// Get usage history
 	usages, err := h.usageRepo.GetHistory(ctx, entityID, months)
 	if err != nil {
-		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to get usage history")
+		writeErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "Failed to get usage history")
 		return
 	}
 
@@ -146,5 +164,5 @@ func (h *UsageHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 		History: history,
 	}
 
-	writeSuccessResponse(w, http.StatusOK, response)
+	writeSuccessResponse(w, r, http.StatusOK, response)
 }
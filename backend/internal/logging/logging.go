@@ -0,0 +1,31 @@
+// Package logging provides the application's structured logger and a helper
+// for correlating log lines with the request that produced them.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// New creates the application's structured (JSON) logger and installs it as
+// the slog default.
+func New() *slog.Logger {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+	return logger
+}
+
+// FromContext returns the default logger with a request_id attribute set
+// from chi's RequestID middleware, if one is present on ctx. Handlers and
+// middleware should use this instead of slog.Default() so log lines can be
+// correlated back to a single request.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if reqID := chimiddleware.GetReqID(ctx); reqID != "" {
+		logger = logger.With("request_id", reqID)
+	}
+	return logger
+}
@@ -2,10 +2,16 @@ package middleware
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
 
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/stellar/torifune/backend/internal/audit"
+	"github.com/stellar/torifune/backend/internal/model"
+	"github.com/stellar/torifune/backend/internal/service/auth"
 )
 
 // contextKey is a custom type for context keys to avoid collisions.
@@ -25,17 +31,50 @@ type Claims struct {
 	Email  string `json:"email"`
 }
 
+// ExtraIssuer configures a trusted external token issuer (CI, a service
+// account, a third-party OIDC provider) that the auth middleware accepts
+// bearer tokens from in addition to Torifune's own locally-issued tokens, so
+// machine-to-machine callers can hit protected endpoints without going
+// through the OAuth login flow. Modeled on oauth2_proxy's
+// -extra-jwt-issuers.
+type ExtraIssuer struct {
+	// Issuer is matched against the token's "iss" claim.
+	Issuer string
+	// JWKSURL is fetched directly for this issuer's signing keys. If empty,
+	// it's discovered from Issuer + "/.well-known/openid-configuration".
+	JWKSURL string
+	// Audience is matched against the token's "aud" claim.
+	Audience string
+	// UserIDClaim is the claim mapped into UserIDKey/Claims.UserID. Defaults
+	// to "sub" when empty.
+	UserIDClaim string
+}
+
 // AuthConfig holds the configuration for the auth middleware.
 type AuthConfig struct {
-	JWTSecret   string
-	SkipPaths   []string
+	// JWTService validates locally-issued access tokens. It selects the
+	// verification key by the token's "kid" header, so it supports every
+	// signing method and every key in the service's current KeySet (current
+	// + previous, across a hot key rotation), not just a single HMAC
+	// secret. It also rejects tokens revoked via AuthHandler.Logout/Revoke.
+	JWTService   *auth.JWTService
+	SkipPaths    []string
 	SkipPrefixes []string
+	// ExtraIssuers lists trusted external issuers verified via JWKS instead
+	// of JWTService. See ExtraIssuer.
+	ExtraIssuers []ExtraIssuer
+	// Auditor records rejected-token events. If nil, rejections aren't audited.
+	Auditor audit.Auditor
 }
 
 // Auth creates a JWT authentication middleware.
 // It validates the Bearer token from the Authorization header and sets
-// user information in the request context.
+// user information in the request context. Tokens whose "iss" claim
+// matches one of cfg.ExtraIssuers are verified against that issuer's JWKS
+// instead of cfg.JWTService.
 func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
+	verifier := newJWKSVerifier(cfg.ExtraIssuers)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Check if the path should bypass authentication
@@ -47,31 +86,25 @@ func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 			// Extract the Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				cfg.auditRejected(r, "missing_authorization_header")
+				model.WriteProblem(w, r, model.NewProblem("missing_authorization_header", http.StatusUnauthorized, "Authorization header required"))
 				return
 			}
 
 			// Check for Bearer token format
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				cfg.auditRejected(r, "invalid_authorization_format")
+				model.WriteProblem(w, r, model.NewProblem("invalid_authorization_format", http.StatusUnauthorized, "Invalid authorization header format"))
 				return
 			}
 
 			tokenString := parts[1]
 
-			// Parse and validate the JWT token
-			claims := &Claims{}
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				// Validate the signing method
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(cfg.JWTSecret), nil
-			})
-
-			if err != nil || !token.Valid {
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			claims, err := cfg.authenticate(r.Context(), verifier, tokenString)
+			if err != nil {
+				cfg.auditRejected(r, "invalid_or_expired_token")
+				model.WriteProblem(w, r, model.NewProblem("invalid_or_expired_token", http.StatusUnauthorized, "Invalid or expired token"))
 				return
 			}
 
@@ -84,6 +117,97 @@ func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 	}
 }
 
+// authenticate validates tokenString, routing it to JWKS verification when
+// its unverified "iss" claim matches a configured ExtraIssuer and falling
+// back to cfg.JWTService otherwise.
+func (cfg AuthConfig) authenticate(ctx context.Context, verifier *jwksVerifier, tokenString string) (*Claims, error) {
+	if len(cfg.ExtraIssuers) > 0 {
+		if iss, ok := peekIssuer(tokenString); ok {
+			if extraIssuer, trusted := verifier.trusts(iss); trusted {
+				return verifyExtraIssuerToken(ctx, verifier, extraIssuer, tokenString)
+			}
+		}
+	}
+
+	claims, err := cfg.JWTService.ValidateAccessToken(ctx, tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("validate access token: %w", err)
+	}
+
+	return &Claims{
+		RegisteredClaims: claims.RegisteredClaims,
+		UserID:           claims.UserID,
+	}, nil
+}
+
+// peekIssuer extracts the "iss" claim from tokenString without verifying
+// its signature, just to decide which key material to verify it against.
+func peekIssuer(tokenString string) (string, bool) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return "", false
+	}
+	iss, err := claims.GetIssuer()
+	if err != nil || iss == "" {
+		return "", false
+	}
+	return iss, true
+}
+
+// verifyExtraIssuerToken verifies tokenString against extraIssuer's JWKS,
+// checks aud/exp/nbf, and maps its UserIDClaim into Claims.
+func verifyExtraIssuerToken(ctx context.Context, verifier *jwksVerifier, extraIssuer ExtraIssuer, tokenString string) (*Claims, error) {
+	mapClaims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, mapClaims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token has no kid header")
+		}
+		return verifier.key(ctx, extraIssuer, kid)
+	}, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid extra-issuer token: %w", err)
+	}
+
+	if extraIssuer.Audience != "" {
+		aud, err := mapClaims.GetAudience()
+		if err != nil || !containsString(aud, extraIssuer.Audience) {
+			return nil, fmt.Errorf("token audience does not match configured audience")
+		}
+	}
+
+	userIDClaim := extraIssuer.UserIDClaim
+	if userIDClaim == "" {
+		userIDClaim = "sub"
+	}
+	userID, _ := mapClaims[userIDClaim].(string)
+	if userID == "" {
+		return nil, fmt.Errorf("token is missing claim %q", userIDClaim)
+	}
+	email, _ := mapClaims["email"].(string)
+
+	registeredClaims := jwt.RegisteredClaims{Issuer: extraIssuer.Issuer, Subject: userID}
+	if exp, err := mapClaims.GetExpirationTime(); err == nil {
+		registeredClaims.ExpiresAt = exp
+	}
+
+	return &Claims{
+		RegisteredClaims: registeredClaims,
+		UserID:           userID,
+		Email:            email,
+	}, nil
+}
+
+// containsString reports whether needle is present in haystack.
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
 // shouldSkipAuth checks if the given path should bypass authentication.
 func shouldSkipAuth(path string, skipPaths, skipPrefixes []string) bool {
 	// Check exact path matches
@@ -122,3 +246,22 @@ func GetClaims(ctx context.Context) *Claims {
 	}
 	return claims
 }
+
+// auditRejected records a rejected-token event, if an Auditor is configured.
+func (cfg AuthConfig) auditRejected(r *http.Request, reason string) {
+	if cfg.Auditor == nil {
+		return
+	}
+
+	_ = cfg.Auditor.LogAuthEvent(r.Context(), audit.Event{
+		ActorID:   "anonymous",
+		ActorType: "user",
+		Action:    "auth.token_rejected",
+		Resource:  r.URL.Path,
+		IP:        GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: chimiddleware.GetReqID(r.Context()),
+		Outcome:   audit.OutcomeDenied,
+		Metadata:  map[string]any{"reason": reason},
+	})
+}
@@ -2,32 +2,107 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+var (
+	defaultAllowedMethods = []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"}
+	defaultAllowedHeaders = []string{"Authorization", "Content-Type"}
+)
+
+// RouteCORS overrides the default allowed methods/headers for requests whose
+// path starts with Prefix, e.g. a public webhook route that only needs POST.
+// The longest matching prefix wins.
+type RouteCORS struct {
+	Prefix         string
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
 // CORSConfig holds the configuration for the CORS middleware.
 type CORSConfig struct {
-	AllowedOrigin string
+	// AllowedOrigins lists the origins allowed to make cross-origin requests.
+	// An entry may be an exact origin ("https://app.torifune.app") or a
+	// wildcard subdomain ("https://*.torifune.app"). "*" allows any origin
+	// (without credentials-safe reflection; use sparingly).
+	AllowedOrigins []string
+
+	// AllowedMethods/AllowedHeaders are the defaults advertised on preflight
+	// responses. Defaults to defaultAllowedMethods/defaultAllowedHeaders.
+	AllowedMethods []string
+	AllowedHeaders []string
+
+	// RouteOverrides overrides AllowedMethods/AllowedHeaders for specific
+	// route prefixes.
+	RouteOverrides []RouteCORS
+
+	// AllowPrivateNetwork answers Chrome's Private Network Access preflight
+	// (Access-Control-Request-Private-Network) by granting
+	// Access-Control-Allow-Private-Network when requested.
+	AllowPrivateNetwork bool
+
+	// MaxAge is the preflight cache duration in seconds. Defaults to 86400.
+	MaxAge int
 }
 
-// CORS creates a Cross-Origin Resource Sharing middleware.
-// It handles preflight OPTIONS requests and sets appropriate CORS headers.
+// CORS creates a Cross-Origin Resource Sharing middleware. It handles
+// preflight OPTIONS requests and sets appropriate CORS headers, matching the
+// request's Origin against AllowedOrigins and reflecting only the methods
+// and headers actually allowed for the matched route.
 func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	allowedMethods := cfg.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = defaultAllowedMethods
+	}
+	allowedHeaders := cfg.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = defaultAllowedHeaders
+	}
+	maxAge := cfg.MaxAge
+	if maxAge <= 0 {
+		maxAge = 86400 // 24 hours
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := cfg.AllowedOrigin
-			if origin == "" {
-				origin = "*"
+			// The response varies by Origin (and, for preflights, by what the
+			// browser is asking permission for), so caches must not share
+			// responses across origins.
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || !OriginAllowed(origin, cfg.AllowedOrigins) {
+				if r.Method == http.MethodOptions {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			// Set CORS headers
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
-			w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
 
-			// Handle preflight OPTIONS request
+			methods, headers := allowedMethods, allowedHeaders
+			if override, ok := matchRouteCORS(r.URL.Path, cfg.RouteOverrides); ok {
+				if len(override.AllowedMethods) > 0 {
+					methods = override.AllowedMethods
+				}
+				if len(override.AllowedHeaders) > 0 {
+					headers = override.AllowedHeaders
+				}
+			}
+
 			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestedOrAllowedHeaders(r, headers), ", "))
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(maxAge))
+
+				if cfg.AllowPrivateNetwork && r.Header.Get("Access-Control-Request-Private-Network") == "true" {
+					w.Header().Set("Access-Control-Allow-Private-Network", "true")
+				}
+
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
@@ -36,3 +111,89 @@ func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// requestedOrAllowedHeaders returns the subset of allowed that the preflight
+// actually asked for (Access-Control-Request-Headers), preserving allowed's
+// canonical casing, so the response doesn't grant headers nobody requested.
+// Falls back to the full allowed list for a non-preflight OPTIONS or a
+// request that didn't specify headers.
+func requestedOrAllowedHeaders(r *http.Request, allowed []string) []string {
+	requested := r.Header.Get("Access-Control-Request-Headers")
+	if requested == "" {
+		return allowed
+	}
+
+	canonical := make(map[string]string, len(allowed))
+	for _, h := range allowed {
+		canonical[strings.ToLower(h)] = h
+	}
+
+	result := make([]string, 0, len(allowed))
+	for _, h := range strings.Split(requested, ",") {
+		if canon, ok := canonical[strings.ToLower(strings.TrimSpace(h))]; ok {
+			result = append(result, canon)
+		}
+	}
+	if len(result) == 0 {
+		return allowed
+	}
+	return result
+}
+
+// matchRouteCORS returns the RouteCORS whose Prefix longest-matches path.
+func matchRouteCORS(path string, overrides []RouteCORS) (RouteCORS, bool) {
+	var best RouteCORS
+	found := false
+	longest := -1
+
+	for _, o := range overrides {
+		if strings.HasPrefix(path, o.Prefix) && len(o.Prefix) > longest {
+			best = o
+			longest = len(o.Prefix)
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// OriginAllowed reports whether origin matches any entry in patterns, where
+// an entry may be an exact origin, "*", or a wildcard subdomain like
+// "https://*.torifune.app". Also used by handler.AuthHandler to validate a
+// client-supplied redirect target against the same allow-list.
+func OriginAllowed(origin string, patterns []string) bool {
+	for _, p := range patterns {
+		if matchOrigin(origin, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOrigin(origin, pattern string) bool {
+	if pattern == "*" || pattern == origin {
+		return true
+	}
+
+	scheme, host, ok := splitOrigin(pattern)
+	if !ok || !strings.HasPrefix(host, "*.") {
+		return false
+	}
+
+	originScheme, originHost, ok := splitOrigin(origin)
+	if !ok || originScheme != scheme {
+		return false
+	}
+
+	suffix := host[1:] // e.g. ".torifune.app"
+	return strings.HasSuffix(originHost, suffix) && len(originHost) > len(suffix)
+}
+
+// splitOrigin splits "scheme://host[:port]" into its scheme and host parts.
+func splitOrigin(o string) (scheme, host string, ok bool) {
+	idx := strings.Index(o, "://")
+	if idx == -1 {
+		return "", "", false
+	}
+	return o[:idx], o[idx+3:], true
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import "testing"
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		pattern string
+		want    bool
+	}{
+		{"exact match", "https://app.torifune.app", "https://app.torifune.app", true},
+		{"exact mismatch", "https://app.torifune.app", "https://other.torifune.app", false},
+		{"wildcard star allows anything", "https://evil.example.com", "*", true},
+		{"wildcard subdomain match", "https://staging.torifune.app", "https://*.torifune.app", true},
+		{"wildcard subdomain scheme mismatch", "http://staging.torifune.app", "https://*.torifune.app", false},
+		{"wildcard subdomain wrong suffix", "https://torifune.app.evil.com", "https://*.torifune.app", false},
+		{"wildcard requires a subdomain, not the bare root", "https://torifune.app", "https://*.torifune.app", false},
+		{"non-wildcard pattern with no scheme separator", "https://app.torifune.app", "app.torifune.app", false},
+		{"origin missing scheme separator", "app.torifune.app", "https://*.torifune.app", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchOrigin(tt.origin, tt.pattern); got != tt.want {
+				t.Errorf("matchOrigin(%q, %q) = %v, want %v", tt.origin, tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	patterns := []string{"https://app.torifune.app", "https://*.staging.torifune.app"}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"matches exact entry", "https://app.torifune.app", true},
+		{"matches wildcard entry", "https://preview-123.staging.torifune.app", true},
+		{"matches no entry", "https://evil.example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OriginAllowed(tt.origin, patterns); got != tt.want {
+				t.Errorf("OriginAllowed(%q, %v) = %v, want %v", tt.origin, patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOriginAllowed_NoPatterns(t *testing.T) {
+	if OriginAllowed("https://app.torifune.app", nil) {
+		t.Error("OriginAllowed with no configured patterns should reject every origin")
+	}
+}
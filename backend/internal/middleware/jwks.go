@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRefreshInterval bounds how long cached JWKS keys are trusted before
+// being re-fetched, so a rotated signing key (new kid) is picked up without
+// requiring a restart.
+const jwksRefreshInterval = 10 * time.Minute
+
+// jwk is a single entry from a JSON Web Key Set, trimmed to the fields
+// needed to reconstruct an RSA or EC public key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwksDocument is the body returned by a JWKS endpoint.
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// oidcDiscoveryDocument is the subset of an OIDC discovery document
+// (<issuer>/.well-known/openid-configuration) needed to locate the issuer's
+// JWKS endpoint when ExtraIssuer.JWKSURL isn't set explicitly.
+type oidcDiscoveryDocument struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// issuerKeySet caches the public keys fetched for one trusted issuer, keyed
+// by kid so token validation can pick the exact key used to sign it.
+type issuerKeySet struct {
+	mu        sync.Mutex
+	jwksURL   string // resolved lazily from ExtraIssuer.JWKSURL or OIDC discovery
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// jwksVerifier resolves and verifies tokens against the JWKS of a set of
+// trusted external issuers (see AuthConfig.ExtraIssuers), caching keys per
+// issuer with periodic refresh so a rotated signing key (new kid) is picked
+// up without requiring a restart.
+type jwksVerifier struct {
+	client  *http.Client
+	issuers map[string]ExtraIssuer
+
+	mu   sync.Mutex
+	sets map[string]*issuerKeySet
+}
+
+func newJWKSVerifier(extraIssuers []ExtraIssuer) *jwksVerifier {
+	issuers := make(map[string]ExtraIssuer, len(extraIssuers))
+	for _, iss := range extraIssuers {
+		issuers[iss.Issuer] = iss
+	}
+	return &jwksVerifier{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		issuers: issuers,
+		sets:    make(map[string]*issuerKeySet),
+	}
+}
+
+// trusts reports whether iss is one of the configured extra issuers, and
+// returns its configuration.
+func (v *jwksVerifier) trusts(iss string) (ExtraIssuer, bool) {
+	cfg, ok := v.issuers[iss]
+	return cfg, ok
+}
+
+// key returns the public key for iss/kid, fetching or refreshing the
+// issuer's JWKS if it's missing or stale.
+func (v *jwksVerifier) key(ctx context.Context, iss ExtraIssuer, kid string) (interface{}, error) {
+	v.mu.Lock()
+	set, ok := v.sets[iss.Issuer]
+	if !ok {
+		set = &issuerKeySet{jwksURL: iss.JWKSURL}
+		v.sets[iss.Issuer] = set
+	}
+	v.mu.Unlock()
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+
+	if key, ok := set.keys[kid]; ok && time.Since(set.fetchedAt) < jwksRefreshInterval {
+		return key, nil
+	}
+
+	if err := v.refreshLocked(ctx, iss, set); err != nil {
+		// A transient refresh error shouldn't take down a kid we already
+		// trust (e.g. a network blip mid-rotation); only surface the error
+		// for a kid we've never seen.
+		if key, ok := set.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, ok := set.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwks: issuer %q has no key for kid %q", iss.Issuer, kid)
+	}
+	return key, nil
+}
+
+// refreshLocked re-fetches and re-parses the issuer's JWKS. Callers must
+// hold set.mu.
+func (v *jwksVerifier) refreshLocked(ctx context.Context, iss ExtraIssuer, set *issuerKeySet) error {
+	jwksURL := set.jwksURL
+	if jwksURL == "" {
+		discovered, err := v.discoverJWKSURL(ctx, iss.Issuer)
+		if err != nil {
+			return fmt.Errorf("jwks: discover JWKS URL for issuer %q: %w", iss.Issuer, err)
+		}
+		jwksURL = discovered
+		set.jwksURL = discovered
+	}
+
+	doc, err := v.fetchJWKS(ctx, jwksURL)
+	if err != nil {
+		return fmt.Errorf("jwks: fetch %s: %w", jwksURL, err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		pubKey, err := parseJWK(k)
+		if err != nil {
+			// Skip keys Torifune doesn't understand (e.g. an unsupported
+			// kty) rather than failing the whole refresh over one entry.
+			continue
+		}
+		keys[k.Kid] = pubKey
+	}
+
+	set.keys = keys
+	set.fetchedAt = time.Now()
+	return nil
+}
+
+func (v *jwksVerifier) discoverJWKSURL(ctx context.Context, issuer string) (string, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var doc oidcDiscoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("decode discovery document: %w", err)
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document has no jwks_uri")
+	}
+	return doc.JWKSURI, nil
+}
+
+func (v *jwksVerifier) fetchJWKS(ctx context.Context, jwksURL string) (*jwksDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+	return &doc, nil
+}
+
+// parseJWK reconstructs a crypto public key (*rsa.PublicKey or
+// *ecdsa.PublicKey) from a JWKS entry. Only the "RSA" and "EC" key types are
+// supported, which covers every OIDC provider Torifune targets.
+func parseJWK(k jwk) (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(nBytes),
+			E: int(new(big.Int).SetBytes(eBytes).Int64()),
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+
+		xBytes, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		yBytes, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(xBytes),
+			Y:     new(big.Int).SetBytes(yBytes),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+}
@@ -1,108 +1,248 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/stellar/torifune/backend/internal/model"
 )
 
+// RouteLimit overrides the default requests-per-minute limit for requests
+// whose path starts with Prefix.
+type RouteLimit struct {
+	Prefix            string
+	RequestsPerMinute int
+}
+
 // RateLimitConfig holds the configuration for the rate limiting middleware.
 type RateLimitConfig struct {
-	// RequestsPerMinute is the maximum number of requests allowed per minute per IP.
-	// Default is 100 if not specified.
+	// RequestsPerMinute is the maximum number of requests allowed per minute per
+	// identity (IP, or authenticated user). Default is 100 if not specified.
 	RequestsPerMinute int
+
+	// RouteLimits overrides RequestsPerMinute for specific route prefixes, e.g.
+	// a stricter limit on "/api/v1/ocr" than "/api/v1/usage". The longest
+	// matching prefix wins.
+	RouteLimits []RouteLimit
+
+	// Store is the counter backend. If nil, an in-memory Store is used.
+	Store Store
+
+	// TrustedProxies lists additional CIDR ranges (e.g. the load balancer in
+	// front of the service) whose X-Forwarded-For/Forwarded entries are
+	// trusted when determining the client IP for keying. See
+	// SetTrustedProxies for the defaults that are always trusted.
+	TrustedProxies []string
 }
 
-// rateLimitEntry tracks request counts for a single IP.
-type rateLimitEntry struct {
+// Store tracks per-key request counts within a fixed window and is the
+// extension point that lets rate limiting work across horizontally scaled
+// instances (e.g. multiple Cloud Run replicas) by sharing state in Redis.
+type Store interface {
+	// Incr increments the counter for key and returns the count after
+	// incrementing along with the remaining TTL of the window. If the key
+	// doesn't exist yet, it is created with the given window as its TTL.
+	Incr(key string, window time.Duration) (count int, ttl time.Duration, err error)
+}
+
+// MemoryStore is an in-memory Store. It does not share state across
+// instances, so it is only suitable for single-instance deployments or as a
+// fallback when no distributed backend is configured.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+type memoryEntry struct {
 	count     int
 	expiresAt time.Time
 }
 
-// rateLimiter manages rate limiting state.
+// NewMemoryStore creates a new in-memory Store and starts its cleanup loop.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{entries: make(map[string]*memoryEntry)}
+	go s.cleanup()
+	return s
+}
+
+// Incr implements Store.
+func (s *MemoryStore) Incr(key string, window time.Duration) (int, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, exists := s.entries[key]
+	if !exists || now.After(entry.expiresAt) {
+		entry = &memoryEntry{count: 0, expiresAt: now.Add(window)}
+		s.entries[key] = entry
+	}
+
+	entry.count++
+	return entry.count, entry.expiresAt.Sub(now), nil
+}
+
+// cleanup periodically removes expired entries to prevent memory leaks.
+func (s *MemoryStore) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for key, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// RedisStore is a Store backed by Redis, using the INCR+EXPIRE pattern so
+// counters are shared across every instance of the service.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a new Redis-backed Store.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Incr implements Store using INCR, setting the expiry only when the key is
+// first created so the window doesn't keep sliding on every request.
+func (s *RedisStore) Incr(key string, window time.Duration) (int, time.Duration, error) {
+	ctx := context.Background()
+
+	count, err := s.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("redis incr: %w", err)
+	}
+
+	if count == 1 {
+		if err := s.client.Expire(ctx, key, window).Err(); err != nil {
+			return 0, 0, fmt.Errorf("redis expire: %w", err)
+		}
+		return int(count), window, nil
+	}
+
+	ttl, err := s.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("redis ttl: %w", err)
+	}
+	if ttl <= 0 {
+		// Lost its TTL somehow (e.g. a races with expiry); reset it so the key
+		// doesn't become permanent.
+		ttl = window
+		_ = s.client.Expire(ctx, key, window).Err()
+	}
+
+	return int(count), ttl, nil
+}
+
+// rateLimiter evaluates requests against a Store and a set of configured limits.
 type rateLimiter struct {
-	mu                sync.RWMutex
-	entries           map[string]*rateLimitEntry
+	store             Store
 	requestsPerMinute int
+	routeLimits       []RouteLimit
 	window            time.Duration
 }
 
 // newRateLimiter creates a new rate limiter instance.
-func newRateLimiter(requestsPerMinute int) *rateLimiter {
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	requestsPerMinute := cfg.RequestsPerMinute
 	if requestsPerMinute <= 0 {
 		requestsPerMinute = 100 // default
 	}
 
-	rl := &rateLimiter{
-		entries:           make(map[string]*rateLimitEntry),
-		requestsPerMinute: requestsPerMinute,
-		window:            time.Minute,
+	store := cfg.Store
+	if store == nil {
+		store = NewMemoryStore()
 	}
 
-	// Start cleanup goroutine
-	go rl.cleanup()
+	if len(cfg.TrustedProxies) > 0 {
+		SetTrustedProxies(cfg.TrustedProxies)
+	}
 
-	return rl
+	return &rateLimiter{
+		store:             store,
+		requestsPerMinute: requestsPerMinute,
+		routeLimits:       cfg.RouteLimits,
+		window:            time.Minute,
+	}
 }
 
-// allow checks if the request from the given IP is allowed.
-// Returns true if allowed, false if rate limited.
-func (rl *rateLimiter) allow(ip string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	entry, exists := rl.entries[ip]
+// limitFor returns the requests-per-minute limit that applies to path, using
+// the longest matching RouteLimit prefix, or the default otherwise.
+func (rl *rateLimiter) limitFor(path string) int {
+	limit := rl.requestsPerMinute
+	longestMatch := -1
 
-	// If entry doesn't exist or has expired, create a new one
-	if !exists || now.After(entry.expiresAt) {
-		rl.entries[ip] = &rateLimitEntry{
-			count:     1,
-			expiresAt: now.Add(rl.window),
+	for _, rule := range rl.routeLimits {
+		if strings.HasPrefix(path, rule.Prefix) && len(rule.Prefix) > longestMatch {
+			limit = rule.RequestsPerMinute
+			longestMatch = len(rule.Prefix)
 		}
-		return true
-	}
-
-	// Check if limit exceeded
-	if entry.count >= rl.requestsPerMinute {
-		return false
 	}
 
-	// Increment counter
-	entry.count++
-	return true
+	return limit
 }
 
-// cleanup periodically removes expired entries to prevent memory leaks.
-func (rl *rateLimiter) cleanup() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
+// allow checks if the request identified by key against the limit for path is
+// allowed. It returns whether the request is allowed plus the values needed
+// for the X-RateLimit-* response headers.
+func (rl *rateLimiter) allow(key, path string) (allowed bool, limit, remaining int, resetAt time.Time) {
+	limit = rl.limitFor(path)
 
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-		for ip, entry := range rl.entries {
-			if now.After(entry.expiresAt) {
-				delete(rl.entries, ip)
-			}
-		}
-		rl.mu.Unlock()
+	count, ttl, err := rl.store.Incr(key, rl.window)
+	if err != nil {
+		// Fail open: a backend hiccup shouldn't take the API down.
+		return true, limit, limit, time.Now().Add(rl.window)
+	}
+
+	remaining = limit - count
+	if remaining < 0 {
+		remaining = 0
 	}
+
+	return count <= limit, limit, remaining, time.Now().Add(ttl)
 }
 
-// RateLimit creates a rate limiting middleware.
-// It limits requests per IP address using an in-memory store.
+// RateLimit creates a rate limiting middleware. Requests are keyed by the
+// authenticated user ID when available (via GetUserID), falling back to the
+// client IP for anonymous requests, so limits apply per-account rather than
+// being trivially shared by everyone behind one NAT.
 func RateLimit(cfg RateLimitConfig) func(http.Handler) http.Handler {
-	limiter := newRateLimiter(cfg.RequestsPerMinute)
+	limiter := newRateLimiter(cfg)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := getClientIP(r)
+			subject := "ip:" + GetClientIP(r)
+			if userID := GetUserID(r.Context()); userID != "" {
+				subject = "user:" + userID
+			}
+
+			bucket := time.Now().Truncate(time.Minute).Unix()
+			key := fmt.Sprintf("%s:%d", subject, bucket)
+
+			allowed, limit, remaining, resetAt := limiter.allow(key, r.URL.Path)
 
-			if !limiter.allow(ip) {
-				w.Header().Set("Retry-After", "60")
-				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(resetAt).Seconds())))
+				model.WriteProblem(w, r, model.NewProblem("rate_limited", http.StatusTooManyRequests, "Too many requests"))
 				return
 			}
 
@@ -111,31 +251,177 @@ func RateLimit(cfg RateLimitConfig) func(http.Handler) http.Handler {
 	}
 }
 
-// getClientIP extracts the client IP address from the request.
-// It checks X-Forwarded-For and X-Real-IP headers first for proxied requests.
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (may contain multiple IPs)
+// defaultTrustedProxyCIDRs are always trusted in addition to whatever is
+// configured via SetTrustedProxies: loopback (local sidecars/load testing)
+// and the private ranges a service mesh or internal load balancer typically
+// connects from.
+var defaultTrustedProxyCIDRs = []string{
+	"127.0.0.0/8",
+	"::1/128",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+}
+
+var (
+	trustedProxyMu   sync.RWMutex
+	trustedProxyNets = parseCIDRs(defaultTrustedProxyCIDRs)
+)
+
+// SetTrustedProxies configures additional CIDR ranges (e.g. a cloud load
+// balancer) whose X-Forwarded-For/Forwarded entries GetClientIP trusts when
+// walking the proxy chain, on top of defaultTrustedProxyCIDRs. Invalid
+// entries are logged and skipped rather than failing startup. Safe to call
+// before the server starts serving traffic.
+func SetTrustedProxies(cidrs []string) {
+	all := make([]string, 0, len(defaultTrustedProxyCIDRs)+len(cidrs))
+	all = append(all, defaultTrustedProxyCIDRs...)
+	all = append(all, cidrs...)
+
+	nets := parseCIDRs(all)
+
+	trustedProxyMu.Lock()
+	trustedProxyNets = nets
+	trustedProxyMu.Unlock()
+}
+
+func parseCIDRs(cidrs []string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			log.Printf("middleware: ignoring invalid trusted proxy CIDR %q: %v", c, err)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	trustedProxyMu.RLock()
+	defer trustedProxyMu.RUnlock()
+
+	for _, n := range trustedProxyNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClientIP extracts the originating client IP address from the request.
+//
+// X-Forwarded-For and Forwarded are attacker-controlled: any client can set
+// them to whatever it likes, so they're only trustworthy once a proxy we
+// actually trust has appended its own hop. GetClientIP therefore only
+// consults them when the direct peer (RemoteAddr) is a trusted proxy (see
+// SetTrustedProxies), then walks the chain right-to-left skipping further
+// trusted-proxy hops, returning the first address that isn't one. If the
+// direct peer isn't trusted, or the header is missing/unparsable, it falls
+// back to RemoteAddr.
+func GetClientIP(r *http.Request) string {
+	remoteIP := hostIP(r.RemoteAddr)
+	if remoteIP == nil {
+		return r.RemoteAddr
+	}
+
+	if !isTrustedProxy(remoteIP) {
+		return remoteIP.String()
+	}
+
+	chain := forwardedChain(r)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i] != nil && !isTrustedProxy(chain[i]) {
+			return chain[i].String()
+		}
+	}
+
+	// Every parsable hop is a trusted proxy (or the chain was empty/entirely
+	// unparsable) — nothing better than the immediate peer is available.
+	return remoteIP.String()
+}
+
+// forwardedChain returns the client/proxy chain from the Forwarded header
+// (preferred, RFC 7239) or X-Forwarded-For, left-to-right as the client sees
+// it (oldest hop first). Entries that don't parse as an IP are nil rather
+// than dropped, so chain position is preserved for the right-to-left walk.
+func forwardedChain(r *http.Request) []net.IP {
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		return parseForwardedHeader(fwd)
+	}
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// Take the first IP in the list
-		if idx := len(xff); idx > 0 {
-			for i, c := range xff {
-				if c == ',' {
-					return xff[:i]
-				}
+		return parseForwardedFor(xff)
+	}
+	return nil
+}
+
+// parseForwardedFor splits a comma-separated X-Forwarded-For value into IPs.
+func parseForwardedFor(value string) []net.IP {
+	parts := strings.Split(value, ",")
+	ips := make([]net.IP, len(parts))
+	for i, p := range parts {
+		ips[i] = parseForwardedAddr(p)
+	}
+	return ips
+}
+
+// parseForwardedHeader parses the RFC 7239 Forwarded header, extracting the
+// "for" parameter of each comma-separated element.
+func parseForwardedHeader(value string) []net.IP {
+	elems := strings.Split(value, ",")
+	ips := make([]net.IP, len(elems))
+	for i, elem := range elems {
+		for _, pair := range strings.Split(elem, ";") {
+			k, v, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if !found || !strings.EqualFold(strings.TrimSpace(k), "for") {
+				continue
 			}
-			return xff
+			ips[i] = parseForwardedAddr(v)
+			break
 		}
 	}
+	return ips
+}
 
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
+// parseForwardedAddr parses a single forwarded-for node identifier, which may
+// be a bare IPv4 address, a bracketed IPv6 address ("[::1]" or
+// "[::1]:1234"), or quoted per RFC 7239 ("\"[::1]:1234\""). Obfuscated
+// identifiers (e.g. "for=unknown" or "for=_hidden") and anything else that
+// doesn't parse as an IP return nil.
+func parseForwardedAddr(s string) net.IP {
+	s = strings.TrimSpace(s)
+	s = strings.Trim(s, `"`)
+	if s == "" {
+		return nil
 	}
 
-	// Fall back to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if strings.HasPrefix(s, "[") {
+		if end := strings.Index(s, "]"); end != -1 {
+			s = s[1:end]
+		}
+	} else if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	if idx := strings.Index(s, "%"); idx != -1 {
+		s = s[:idx] // strip IPv6 zone, e.g. "fe80::1%eth0"
+	}
+
+	return net.ParseIP(s)
+}
+
+// hostIP parses the IP portion of a "host:port" address, falling back to
+// treating the whole string as a bare IP if it has no port.
+func hostIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
-		return r.RemoteAddr
+		host = addr
 	}
-	return ip
+	return net.ParseIP(host)
 }
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetClientIP(t *testing.T) {
+	// All these tests see the default trusted proxies (loopback + RFC1918 +
+	// ULA) plus 203.0.113.0/24, standing in for a load balancer's range.
+	SetTrustedProxies([]string{"203.0.113.0/24"})
+	t.Cleanup(func() { SetTrustedProxies(nil) })
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "no proxy, untrusted peer, XFF ignored",
+			remoteAddr: "198.51.100.5:12345",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "198.51.100.5",
+		},
+		{
+			name:       "spoofed XFF from an untrusted direct peer is ignored",
+			remoteAddr: "198.51.100.5:12345",
+			headers:    map[string]string{"X-Forwarded-For": "9.9.9.9, 203.0.113.1"},
+			want:       "198.51.100.5",
+		},
+		{
+			name:       "trusted proxy, XFF walked right-to-left to the first non-trusted hop",
+			remoteAddr: "203.0.113.1:443",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.9"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "trusted proxy chain of only trusted hops falls back to the peer",
+			remoteAddr: "203.0.113.1:443",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.9, 10.0.0.5"},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "IPv6 zone is stripped before parsing",
+			remoteAddr: "203.0.113.1:443",
+			headers:    map[string]string{"X-Forwarded-For": "fe80::1%eth0"},
+			want:       "fe80::1",
+		},
+		{
+			name:       "malformed XFF entries are skipped, not trusted",
+			remoteAddr: "203.0.113.1:443",
+			headers:    map[string]string{"X-Forwarded-For": "not-an-ip, also bogus"},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "Forwarded header takes priority over X-Forwarded-For",
+			remoteAddr: "203.0.113.1:443",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8::1]:4711"`,
+				"X-Forwarded-For": "9.9.9.9",
+			},
+			want: "2001:db8::1",
+		},
+		{
+			name:       "Forwarded header with obfuscated identifier falls back to peer",
+			remoteAddr: "203.0.113.1:443",
+			headers:    map[string]string{"Forwarded": "for=unknown"},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "RemoteAddr with no port is used as-is",
+			remoteAddr: "198.51.100.5",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "198.51.100.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			if got := GetClientIP(req); got != tt.want {
+				t.Errorf("GetClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/stellar/torifune/backend/internal/logging"
+	"github.com/stellar/torifune/backend/internal/service/auth"
+)
+
+// oauthTokenKey is the context key for the OAuthToken injected by
+// WithProviderToken.
+const oauthTokenKey contextKey = "oauthToken"
+
+// WithProviderToken returns middleware that resolves a fresh OAuthToken for
+// the authenticated user (see GetUserID) and provider, refreshing it via
+// refresher first if it's close to expiring, and stores it in the request
+// context for handlers to retrieve with ProviderTokenFromContext.
+//
+// Requests with no authenticated user, or for a user with no stored token
+// for provider, proceed unmodified; handlers that require a token should
+// check ProviderTokenFromContext explicitly rather than assume one is set.
+func WithProviderToken(refresher *auth.TokenRefresher, provider auth.ProviderType) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := GetUserID(r.Context())
+			if userID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, err := refresher.Get(r.Context(), userID, provider)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("failed to resolve provider token",
+					"provider", provider, "user_id", userID, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), oauthTokenKey, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ProviderTokenFromContext retrieves the OAuthToken injected by
+// WithProviderToken. Returns nil if none was set.
+func ProviderTokenFromContext(ctx context.Context) *auth.OAuthToken {
+	token, ok := ctx.Value(oauthTokenKey).(*auth.OAuthToken)
+	if !ok {
+		return nil
+	}
+	return token
+}
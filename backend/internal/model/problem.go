@@ -0,0 +1,175 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/stellar/torifune/backend/internal/service/auth"
+)
+
+// problemTypeBase roots every Problem.Type URI this service mints. It
+// doesn't need to resolve to anything; RFC 7807 only requires Type to be a
+// stable identifier clients can key off of.
+const problemTypeBase = "https://torifune.app/problems/"
+
+// Problem is an RFC 7807 "problem detail" error response.
+type Problem struct {
+	Type     string `json:"-"`
+	Title    string `json:"-"`
+	Status   int    `json:"-"`
+	Detail   string `json:"-"`
+	Instance string `json:"-"`
+	// Extensions carries additional members RFC 7807 section 3.2 allows a
+	// problem type to define, e.g. "invalid_params" or "trace_id".
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// InvalidParam names one request field that failed validation and why,
+// used in a ValidationProblem's "invalid_params" extension member.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Error implements error, so a Problem can be returned and propagated like
+// any other error and later recovered by WriteProblem via errors.As.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// MarshalJSON renders p as the RFC 7807 document, merging Extensions in
+// alongside the standard members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		fields[k] = v
+	}
+	fields["type"] = p.Type
+	fields["title"] = p.Title
+	fields["status"] = p.Status
+	if p.Detail != "" {
+		fields["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		fields["instance"] = p.Instance
+	}
+	return json.Marshal(fields)
+}
+
+// NewProblem builds a Problem whose Type is a URI under problemTypeBase for
+// code, a short machine-readable slug (e.g. "invalid_request") in the same
+// style the old ErrorResponse.Error field used. Title is derived from
+// status via http.StatusText.
+func NewProblem(code string, status int, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypeBase + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// ValidationProblem builds a 400 Problem for a request that failed field
+// validation, with one InvalidParam entry per invalid field.
+func ValidationProblem(detail string, invalidParams ...InvalidParam) *Problem {
+	p := NewProblem("invalid_request", http.StatusBadRequest, detail)
+	if len(invalidParams) > 0 {
+		p.Extensions = map[string]interface{}{"invalid_params": invalidParams}
+	}
+	return p
+}
+
+// AuthProblem maps a sentinel error from the auth package to the matching
+// Problem and HTTP status, falling back to a generic 401 for any other
+// error.
+func AuthProblem(err error) *Problem {
+	switch {
+	case errors.Is(err, auth.ErrExpiredToken):
+		return NewProblem("token_expired", http.StatusUnauthorized, err.Error())
+	case errors.Is(err, auth.ErrInvalidTokenType):
+		return NewProblem("invalid_token_type", http.StatusForbidden, err.Error())
+	case errors.Is(err, auth.ErrRevokedToken):
+		return NewProblem("token_revoked", http.StatusUnauthorized, err.Error())
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		return NewProblem("refresh_token_reused", http.StatusUnauthorized, err.Error())
+	case errors.Is(err, auth.ErrInvalidToken):
+		return NewProblem("invalid_token", http.StatusUnauthorized, err.Error())
+	default:
+		return NewProblem("unauthorized", http.StatusUnauthorized, err.Error())
+	}
+}
+
+// authSentinelErrors are recognized by WriteProblem and mapped via
+// AuthProblem instead of falling back to a generic 500.
+var authSentinelErrors = []error{
+	auth.ErrExpiredToken,
+	auth.ErrInvalidTokenType,
+	auth.ErrRevokedToken,
+	auth.ErrRefreshTokenReused,
+	auth.ErrInvalidToken,
+}
+
+// WriteProblem writes err as an RFC 7807 problem+json response. If err is
+// already a *Problem (or wraps one), it's written as-is; a recognized auth
+// sentinel error is mapped via AuthProblem; anything else becomes a
+// generic 500 Internal Server Error Problem. The response's trace_id
+// extension is set from r's chi request ID (see logging.FromContext), so
+// it can be correlated with server logs. Clients that send a plain
+// "application/json" Accept header (and not "application/problem+json")
+// still get the same JSON body, just without the problem+json content
+// type, since the body shape is a strict superset of the old
+// ErrorResponse.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	var p *Problem
+	switch {
+	case errors.As(err, &p):
+		// already a Problem
+	case isAuthSentinel(err):
+		p = AuthProblem(err)
+	default:
+		p = NewProblem("internal_error", http.StatusInternalServerError, err.Error())
+	}
+
+	if traceID := chimiddleware.GetReqID(r.Context()); traceID != "" {
+		p = p.withExtension("trace_id", traceID)
+	}
+
+	contentType := "application/problem+json"
+	accept := r.Header.Get("Accept")
+	if accept != "" && !strings.Contains(accept, "problem+json") && strings.Contains(accept, "application/json") {
+		contentType = "application/json"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// isAuthSentinel reports whether err matches one of authSentinelErrors.
+func isAuthSentinel(err error) bool {
+	for _, sentinel := range authSentinelErrors {
+		if errors.Is(err, sentinel) {
+			return true
+		}
+	}
+	return false
+}
+
+// withExtension returns a copy of p with key set in Extensions, leaving p
+// itself untouched.
+func (p *Problem) withExtension(key string, value interface{}) *Problem {
+	clone := *p
+	clone.Extensions = make(map[string]interface{}, len(p.Extensions)+1)
+	for k, v := range p.Extensions {
+		clone.Extensions[k] = v
+	}
+	clone.Extensions[key] = value
+	return &clone
+}
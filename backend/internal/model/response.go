@@ -1,13 +1,32 @@
 package model
 
-// ErrorResponse represents an error response returned to clients.
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-}
+import (
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
 
 // SuccessResponse represents a successful response returned to clients.
 type SuccessResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 }
+
+// Envelope is SuccessResponse plus a trace_id, for handlers that want every
+// response to carry one for correlating with server logs (see
+// logging.FromContext). Use NewEnvelope to fill TraceID from the request.
+type Envelope struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	TraceID string      `json:"trace_id,omitempty"`
+}
+
+// NewEnvelope wraps data in an Envelope, pulling TraceID from r's chi
+// request ID.
+func NewEnvelope(r *http.Request, data interface{}) Envelope {
+	return Envelope{
+		Success: true,
+		Data:    data,
+		TraceID: chimiddleware.GetReqID(r.Context()),
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// SchemaFieldType constrains a SchemaField's declared value type, used to
+// validate extracted values before they're returned to the caller.
+type SchemaFieldType string
+
+const (
+	SchemaFieldTypeString   SchemaFieldType = "string"
+	SchemaFieldTypeDate     SchemaFieldType = "date"
+	SchemaFieldTypeCurrency SchemaFieldType = "currency"
+	SchemaFieldTypeEmail    SchemaFieldType = "email"
+	SchemaFieldTypeEnum     SchemaFieldType = "enum"
+)
+
+// SchemaField describes a single field an ExtractionSchema expects to find
+// in a document.
+type SchemaField struct {
+	// Name is both the field's display name and the key it's returned under
+	// in ProcessResponse.Fields; it's matched case-sensitively against
+	// Document AI entity/form-field types.
+	Name string          `firestore:"name" json:"name"`
+	Type SchemaFieldType `firestore:"type" json:"type"`
+	// RegexHint, if set, is matched against the document's full text as a
+	// fallback when Document AI doesn't return an entity or form field named
+	// Name (e.g. for processors not trained to recognize it explicitly).
+	RegexHint string `firestore:"regexHint,omitempty" json:"regexHint,omitempty"`
+	// PromptHint documents what this field means for whoever is configuring
+	// the schema (e.g. training a custom Document AI processor); it isn't
+	// used at extraction time.
+	PromptHint string `firestore:"promptHint,omitempty" json:"promptHint,omitempty"`
+	// EnumValues constrains accepted values when Type is
+	// SchemaFieldTypeEnum; matching is case-insensitive.
+	EnumValues []string `firestore:"enumValues,omitempty" json:"enumValues,omitempty"`
+	// Required marks fields callers are expected to populate; it's exposed
+	// for API consumers to validate against but isn't enforced server-side.
+	Required bool `firestore:"required,omitempty" json:"required,omitempty"`
+}
+
+// ExtractionSchema is a named, per-organization template for structured
+// field extraction: which fields to pull out of a document, with optional
+// extraction hints and validation, and optionally which Document AI
+// processor to route matching documents through instead of the one the
+// deployment picks by MIME type (form parser or general OCR), e.g. a custom
+// processor trained on a specific invoice or ID-card layout.
+type ExtractionSchema struct {
+	ID             string `firestore:"id" json:"id"`
+	OrganizationID string `firestore:"organizationId" json:"organizationId"`
+	Name           string `firestore:"name" json:"name"`
+	// ProcessorID, if set, is a Document AI processor ID used in place of
+	// the deployment's form parser or general OCR processor for documents
+	// processed against this schema, whatever their MIME type.
+	ProcessorID string        `firestore:"processorId,omitempty" json:"processorId,omitempty"`
+	Fields      []SchemaField `firestore:"fields" json:"fields"`
+	CreatedAt   time.Time     `firestore:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time     `firestore:"updatedAt" json:"updatedAt"`
+}
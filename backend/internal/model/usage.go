@@ -12,3 +12,13 @@ type MonthlyUsage struct {
 	FreeLimit      int       `firestore:"freeLimit" json:"freeLimit"`
 	UpdatedAt      time.Time `firestore:"updatedAt" json:"updatedAt"`
 }
+
+// DailyUsage tracks a single user's processed count within a specific day,
+// independent of any organization's monthly usage, so usage.PolicyEngine can
+// enforce a per-user daily cap alongside the per-org monthly one.
+type DailyUsage struct {
+	UserID         string    `firestore:"userId" json:"userId"`
+	Day            string    `firestore:"day" json:"day"` // "20240115"
+	ProcessedCount int       `firestore:"processedCount" json:"processedCount"`
+	UpdatedAt      time.Time `firestore:"updatedAt" json:"updatedAt"`
+}
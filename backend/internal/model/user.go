@@ -4,16 +4,30 @@ import "time"
 
 // User represents an authenticated user in the system.
 type User struct {
-	ID          string    `firestore:"id" json:"id"`
-	Email       string    `firestore:"email" json:"email"`
-	Name        string    `firestore:"name" json:"name"`
-	Picture     string    `firestore:"picture" json:"picture"`
-	Provider    string    `firestore:"provider" json:"provider"`       // "google", "microsoft", "slack"
-	ProviderID  string    `firestore:"providerId" json:"providerId"`
-	WorkspaceID string    `firestore:"workspaceId,omitempty" json:"workspaceId,omitempty"` // for Slack
-	Tier        string    `firestore:"tier" json:"tier"`               // "free", "pro"
-	CreatedAt   time.Time `firestore:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time `firestore:"updatedAt" json:"updatedAt"`
+	ID          string `firestore:"id" json:"id"`
+	Email       string `firestore:"email" json:"email"`
+	Name        string `firestore:"name" json:"name"`
+	Picture     string `firestore:"picture" json:"picture"`
+	Provider    string `firestore:"provider" json:"provider"` // "google", "microsoft", "slack"
+	ProviderID  string `firestore:"providerId" json:"providerId"`
+	WorkspaceID string `firestore:"workspaceId,omitempty" json:"workspaceId,omitempty"` // for Slack
+	// SlackBotAccessToken is the workspace-level bot token ("access_token" in
+	// Slack's OAuth response) used to call the Slack API on the workspace's
+	// behalf, e.g. downloading files or posting messages. Never serialized to
+	// JSON since it's a credential, not user-facing profile data.
+	SlackBotAccessToken string `firestore:"slackBotAccessToken,omitempty" json:"-"`
+	// SlackUserAccessToken is the authorizing user's token
+	// ("authed_user.access_token"), kept separately from the bot token since
+	// the two have different scopes.
+	SlackUserAccessToken string `firestore:"slackUserAccessToken,omitempty" json:"-"`
+	Tier                 string `firestore:"tier" json:"tier"` // "free", "pro"
+	// OrganizationID, if set, is the Organization this user belongs to. It
+	// scopes access to organization-owned resources like ExtractionSchema:
+	// a user can only act on schemas whose OrganizationID matches this
+	// value. Empty for users who haven't joined an organization.
+	OrganizationID string    `firestore:"organizationId,omitempty" json:"organizationId,omitempty"`
+	CreatedAt      time.Time `firestore:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time `firestore:"updatedAt" json:"updatedAt"`
 }
 
 // Organization represents a company or team that groups users together.
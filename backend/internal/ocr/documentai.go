@@ -0,0 +1,127 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	documentai "cloud.google.com/go/documentai/apiv1"
+	documentaipb "cloud.google.com/go/documentai/apiv1/documentaipb"
+
+	"github.com/stellar/torifune/backend/internal/model"
+	"github.com/stellar/torifune/backend/internal/repository"
+)
+
+// ErrSchemaForbidden is returned by DocumentAIProcessor.Process when
+// Input.SchemaID resolves to a schema belonging to an organization other
+// than Input.OrganizationID.
+var ErrSchemaForbidden = errors.New("ocr: schema belongs to another organization")
+
+// DocumentAIProcessor implements Processor using Google Document AI. It
+// picks between the form parser and general OCR processor depending on the
+// document's MIME type: PDFs go through the form parser so structured field
+// extraction is available, everything else (plain images) uses the general
+// OCR processor. A schema (see Input.SchemaID) overrides this with its own
+// ProcessorID when set.
+type DocumentAIProcessor struct {
+	client       *documentai.DocumentProcessorClient
+	projectID    string
+	location     string
+	formParserID string
+	generalOCRID string
+	// schemas resolves Input.SchemaID to a model.ExtractionSchema. May be
+	// nil, in which case SchemaID is ignored and Fields is populated from
+	// Document AI's raw entities as before.
+	schemas repository.SchemaRepository
+}
+
+// NewDocumentAIProcessor creates a DocumentAIProcessor. schemas may be nil
+// if schema-driven extraction isn't configured for this deployment.
+func NewDocumentAIProcessor(client *documentai.DocumentProcessorClient, projectID, location, formParserID, generalOCRID string, schemas repository.SchemaRepository) *DocumentAIProcessor {
+	return &DocumentAIProcessor{
+		client:       client,
+		projectID:    projectID,
+		location:     location,
+		formParserID: formParserID,
+		generalOCRID: generalOCRID,
+		schemas:      schemas,
+	}
+}
+
+// Process implements Processor via Document AI's synchronous
+// ProcessDocument API.
+func (p *DocumentAIProcessor) Process(ctx context.Context, in Input) (*Result, error) {
+	var schema *model.ExtractionSchema
+	if in.SchemaID != "" && p.schemas != nil {
+		resolved, err := p.schemas.GetByID(ctx, in.SchemaID)
+		if err != nil {
+			return nil, fmt.Errorf("documentai: resolve schema %q: %w", in.SchemaID, err)
+		}
+		if resolved.OrganizationID != in.OrganizationID {
+			return nil, ErrSchemaForbidden
+		}
+		schema = resolved
+	}
+
+	processorID := p.generalOCRID
+	switch {
+	case schema != nil && schema.ProcessorID != "":
+		processorID = schema.ProcessorID
+	case in.MimeType == "application/pdf":
+		processorID = p.formParserID
+	}
+
+	resp, err := p.client.ProcessDocument(ctx, &documentaipb.ProcessRequest{
+		Name: fmt.Sprintf("projects/%s/locations/%s/processors/%s", p.projectID, p.location, processorID),
+		Source: &documentaipb.ProcessRequest_RawDocument{
+			RawDocument: &documentaipb.RawDocument{
+				Content:  in.Data,
+				MimeType: in.MimeType,
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("documentai: process document: %w", err)
+	}
+
+	doc := resp.GetDocument()
+	result := &Result{
+		Text: doc.GetText(),
+	}
+
+	var totalConfidence float64
+	var pageCount int
+	for _, page := range doc.GetPages() {
+		pageCount++
+		totalConfidence += float64(page.GetLayout().GetConfidence())
+	}
+	if pageCount > 0 {
+		result.Confidence = totalConfidence / float64(pageCount)
+	}
+
+	raw := make(map[string]rawEntity)
+	for _, entity := range doc.GetEntities() {
+		if entity.GetType() == "" {
+			continue
+		}
+		raw[entity.GetType()] = rawEntity{
+			Value:      entity.GetMentionText(),
+			Confidence: float64(entity.GetConfidence()),
+		}
+	}
+
+	if schema != nil {
+		result.Fields = make(map[string]any, len(schema.Fields))
+		for name, value := range applySchema(schema, result.Text, raw) {
+			result.Fields[name] = value
+		}
+		return result, nil
+	}
+
+	result.Fields = make(map[string]any, len(raw))
+	for name, entity := range raw {
+		result.Fields[name] = entity.Value
+	}
+
+	return result, nil
+}
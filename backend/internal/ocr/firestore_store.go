@@ -0,0 +1,74 @@
+package ocr
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreStore implements Store using Firestore.
+type FirestoreStore struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreStore creates a new FirestoreStore.
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client, collection: "ocr_jobs"}
+}
+
+// Create implements Store.
+func (s *FirestoreStore) Create(ctx context.Context, job *Job) error {
+	ref := s.client.Collection(s.collection).NewDoc()
+	job.ID = ref.ID
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+
+	_, err := ref.Set(ctx, job)
+	return err
+}
+
+// Get implements Store.
+func (s *FirestoreStore) Get(ctx context.Context, id string) (*Job, error) {
+	doc, err := s.client.Collection(s.collection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	var job Job
+	if err := doc.DataTo(&job); err != nil {
+		return nil, err
+	}
+	job.ID = doc.Ref.ID
+
+	return &job, nil
+}
+
+// Update implements Store.
+func (s *FirestoreStore) Update(ctx context.Context, id string, status JobStatus, results []Result, jobErr string) error {
+	_, err := s.client.Collection(s.collection).Doc(id).Set(ctx, map[string]any{
+		"status":    status,
+		"results":   results,
+		"error":     jobErr,
+		"updatedAt": time.Now(),
+	}, firestore.MergeAll)
+	return err
+}
+
+// CountActive implements Store.
+func (s *FirestoreStore) CountActive(ctx context.Context, entityID string) (int, error) {
+	docs, err := s.client.Collection(s.collection).
+		Where("entityId", "==", entityID).
+		Where("status", "in", []JobStatus{JobStatusQueued, JobStatusProcessing}).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
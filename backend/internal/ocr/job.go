@@ -0,0 +1,68 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// JobStatus is the lifecycle state of an async OCR job.
+type JobStatus string
+
+const (
+	JobStatusQueued     JobStatus = "queued"
+	JobStatusProcessing JobStatus = "processing"
+	JobStatusSucceeded  JobStatus = "succeeded"
+	JobStatusFailed     JobStatus = "failed"
+)
+
+// ErrJobNotFound is returned when a job ID doesn't exist.
+var ErrJobNotFound = errors.New("ocr: job not found")
+
+// JobItem is a single document within a batch job, staged in GCS so both the
+// API instance that dispatched the job and the worker that processes it (not
+// necessarily the same instance) can access it.
+type JobItem struct {
+	GCSInputURI string `firestore:"gcsInputUri" json:"gcsInputUri"`
+	MimeType    string `firestore:"mimeType" json:"mimeType"`
+	// SchemaID, if set, is passed through to Input.SchemaID when the worker
+	// processes this item.
+	SchemaID string `firestore:"schemaId,omitempty" json:"schemaId,omitempty"`
+	// OrganizationID is passed through to Input.OrganizationID when the
+	// worker processes this item, recorded at dispatch time from the
+	// caller's own profile rather than the request.
+	OrganizationID string `firestore:"organizationId,omitempty" json:"organizationId,omitempty"`
+}
+
+// Job is an asynchronous OCR batch job.
+type Job struct {
+	ID         string    `firestore:"-" json:"id"`
+	EntityID   string    `firestore:"entityId" json:"entityId"`
+	EntityType string    `firestore:"entityType" json:"entityType"`
+	Status     JobStatus `firestore:"status" json:"status"`
+	Items      []JobItem `firestore:"items" json:"items"`
+	Results    []Result  `firestore:"results,omitempty" json:"results,omitempty"`
+	Error      string    `firestore:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time `firestore:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time `firestore:"updatedAt" json:"updatedAt"`
+}
+
+// Store persists job state so both the API instance that dispatches a job
+// and the worker that processes it can read and update it independently.
+type Store interface {
+	Create(ctx context.Context, job *Job) error
+	Get(ctx context.Context, id string) (*Job, error)
+	Update(ctx context.Context, id string, status JobStatus, results []Result, jobErr string) error
+
+	// CountActive returns the number of entityID's jobs that are queued or
+	// processing, used by usage.PolicyEngine to enforce a per-entity
+	// concurrent job limit.
+	CountActive(ctx context.Context, entityID string) (int, error)
+}
+
+// Queue dispatches a job for async processing. It only needs to wake up a
+// worker (e.g. via Pub/Sub) — job state itself lives in Store — so who
+// triggers processing stays decoupled from who tracks its progress.
+type Queue interface {
+	Enqueue(ctx context.Context, jobID string) error
+}
@@ -0,0 +1,48 @@
+// Package ocr implements the OCR processing subsystem: a Processor
+// abstraction over Document AI, and the async job queue used for batches too
+// large to process inline within a single request.
+package ocr
+
+import "context"
+
+// InlineSizeThreshold is the largest decoded document size processed
+// synchronously inline. Anything larger is routed through the async job
+// queue instead, since Document AI's synchronous API caps request size and
+// processing time.
+const InlineSizeThreshold = 20 * 1024 * 1024 // 20MB
+
+// Input describes a single document to run OCR on.
+type Input struct {
+	Data     []byte
+	MimeType string
+	// SchemaID, if set, routes processing through the named
+	// model.ExtractionSchema: its ProcessorID (if set) is used in place of
+	// the form parser or general OCR processor the MIME type would pick, and
+	// its Fields drive structured extraction
+	// into Result.Fields instead of the raw entity dump.
+	SchemaID string
+	// OrganizationID is the organization the caller belongs to, resolved
+	// server-side rather than taken from the request. When SchemaID is set,
+	// DocumentAIProcessor.Process rejects the document unless the resolved
+	// schema's OrganizationID matches, so a caller can't route documents
+	// through another organization's schema (and paid processor) by
+	// guessing its ID.
+	OrganizationID string
+}
+
+// Result is the outcome of running OCR on a single document.
+type Result struct {
+	Text       string  `firestore:"text" json:"text"`
+	Confidence float64 `firestore:"confidence" json:"confidence"`
+	// Fields contains structured field extraction results: FieldValue when
+	// Input.SchemaID was set, or a plain string per named entity otherwise.
+	Fields map[string]any `firestore:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// Processor extracts text (and, for supported document types, structured
+// fields) from a document. It's used for the synchronous inline path;
+// documents large enough to need the async path are instead routed through
+// a Queue (see job.go) and processed by a Worker.
+type Processor interface {
+	Process(ctx context.Context, in Input) (*Result, error)
+}
@@ -0,0 +1,28 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// PubSubQueue dispatches jobs by publishing the job ID to a Pub/Sub topic;
+// a Worker subscribed to that topic drives the job to completion.
+type PubSubQueue struct {
+	topic *pubsub.Topic
+}
+
+// NewPubSubQueue creates a new PubSubQueue publishing to topic.
+func NewPubSubQueue(topic *pubsub.Topic) *PubSubQueue {
+	return &PubSubQueue{topic: topic}
+}
+
+// Enqueue implements Queue.
+func (q *PubSubQueue) Enqueue(ctx context.Context, jobID string) error {
+	result := q.topic.Publish(ctx, &pubsub.Message{Data: []byte(jobID)})
+	if _, err := result.Get(ctx); err != nil {
+		return fmt.Errorf("ocr: publish job %s: %w", jobID, err)
+	}
+	return nil
+}
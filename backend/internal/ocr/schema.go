@@ -0,0 +1,110 @@
+package ocr
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/stellar/torifune/backend/internal/model"
+)
+
+// FieldValue is a single structured field extracted from a document, mapped
+// against a model.ExtractionSchema field.
+type FieldValue struct {
+	Value string `firestore:"value" json:"value"`
+	// Confidence is Document AI's confidence in this extraction; it's 0 when
+	// the value instead came from a SchemaField.RegexHint match.
+	Confidence float64 `firestore:"confidence" json:"confidence"`
+	// Valid is false when Value didn't pass the validation expected for its
+	// SchemaField type (e.g. an "email" field that isn't a valid address).
+	// It's still returned rather than dropped, so callers can flag it for
+	// manual review instead of silently losing the extraction.
+	Valid bool `firestore:"valid" json:"valid"`
+}
+
+// rawEntity is a raw, unvalidated field/entity Document AI returned, keyed
+// by its type/name before it's been mapped onto a schema.
+type rawEntity struct {
+	Value      string
+	Confidence float64
+}
+
+// currencyPattern matches a plain or currency-prefixed decimal amount, e.g.
+// "$1,234.56" or "1234".
+var currencyPattern = regexp.MustCompile(`^[+-]?[$€£¥]?\s?\d[\d,]*(\.\d{1,2})?$`)
+
+// dateLayouts are the layouts tried, in order, to validate a "date" field.
+var dateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"2006/01/02",
+	time.RFC3339,
+	"Jan 2, 2006",
+	"January 2, 2006",
+}
+
+// validateField reports whether value satisfies field's declared type.
+// Fields without a recognized type (including SchemaFieldTypeString) always
+// validate.
+func validateField(field model.SchemaField, value string) bool {
+	switch field.Type {
+	case model.SchemaFieldTypeDate:
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return true
+			}
+		}
+		return false
+	case model.SchemaFieldTypeCurrency:
+		return currencyPattern.MatchString(strings.TrimSpace(value))
+	case model.SchemaFieldTypeEmail:
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case model.SchemaFieldTypeEnum:
+		for _, allowed := range field.EnumValues {
+			if strings.EqualFold(allowed, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// applySchema maps raw into schema's declared fields, falling back to each
+// field's RegexHint against text when raw has no matching entry, and
+// validates every resulting value against its declared type. Fields with
+// neither a raw match nor a hint match are omitted rather than returned
+// empty.
+func applySchema(schema *model.ExtractionSchema, text string, raw map[string]rawEntity) map[string]FieldValue {
+	fields := make(map[string]FieldValue, len(schema.Fields))
+
+	for _, field := range schema.Fields {
+		if entity, ok := raw[field.Name]; ok {
+			fields[field.Name] = FieldValue{
+				Value:      entity.Value,
+				Confidence: entity.Confidence,
+				Valid:      validateField(field, entity.Value),
+			}
+			continue
+		}
+
+		if field.RegexHint == "" {
+			continue
+		}
+		re, err := regexp.Compile(field.RegexHint)
+		if err != nil {
+			continue
+		}
+		if match := re.FindString(text); match != "" {
+			fields[field.Name] = FieldValue{
+				Value: match,
+				Valid: validateField(field, match),
+			}
+		}
+	}
+
+	return fields
+}
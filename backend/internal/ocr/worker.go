@@ -0,0 +1,112 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
+)
+
+// QuotaReleaser rolls back reserved usage quota, e.g. when an async job
+// fails after quota was reserved for it up front.
+type QuotaReleaser interface {
+	ReleaseQuota(ctx context.Context, entityID, entityType string, count int) error
+}
+
+// Worker consumes job IDs from a Pub/Sub subscription and drives each job to
+// completion, so dispatching (the API process) and processing (this worker)
+// scale independently.
+type Worker struct {
+	sub       *pubsub.Subscription
+	gcs       *storage.Client
+	store     Store
+	processor Processor
+	quota     QuotaReleaser
+	logger    *slog.Logger
+}
+
+// NewWorker creates a new Worker.
+func NewWorker(sub *pubsub.Subscription, gcs *storage.Client, store Store, processor Processor, quota QuotaReleaser, logger *slog.Logger) *Worker {
+	return &Worker{sub: sub, gcs: gcs, store: store, processor: processor, quota: quota, logger: logger}
+}
+
+// Run blocks, processing jobs until ctx is canceled or the subscription's
+// Receive call returns.
+func (w *Worker) Run(ctx context.Context) error {
+	return w.sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		jobID := string(msg.Data)
+		if err := w.processJob(ctx, jobID); err != nil {
+			w.logger.Error("ocr: job processing failed", "job_id", jobID, "error", err)
+			msg.Nack()
+			return
+		}
+		msg.Ack()
+	})
+}
+
+func (w *Worker) processJob(ctx context.Context, jobID string) error {
+	job, err := w.store.Get(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	if err := w.store.Update(ctx, jobID, JobStatusProcessing, nil, ""); err != nil {
+		return err
+	}
+
+	results := make([]Result, 0, len(job.Items))
+	for _, item := range job.Items {
+		data, err := w.download(ctx, item.GCSInputURI)
+		if err != nil {
+			return w.fail(ctx, job, err)
+		}
+
+		result, err := w.processor.Process(ctx, Input{Data: data, MimeType: item.MimeType, SchemaID: item.SchemaID, OrganizationID: item.OrganizationID})
+		if err != nil {
+			return w.fail(ctx, job, err)
+		}
+		results = append(results, *result)
+	}
+
+	return w.store.Update(ctx, jobID, JobStatusSucceeded, results, "")
+}
+
+// fail marks job as failed and releases the quota reserved for it up front,
+// so a processing failure doesn't silently cost the entity its monthly
+// allowance.
+func (w *Worker) fail(ctx context.Context, job *Job, cause error) error {
+	if relErr := w.quota.ReleaseQuota(ctx, job.EntityID, job.EntityType, len(job.Items)); relErr != nil {
+		w.logger.Error("ocr: failed to release reserved quota after job failure", "job_id", job.ID, "error", relErr)
+	}
+
+	if err := w.store.Update(ctx, job.ID, JobStatusFailed, nil, cause.Error()); err != nil {
+		w.logger.Error("ocr: failed to record job failure", "job_id", job.ID, "error", err)
+	}
+
+	return cause
+}
+
+// download reads the full contents of a gs://bucket/object URI.
+func (w *Worker) download(ctx context.Context, gcsURI string) ([]byte, error) {
+	path, ok := strings.CutPrefix(gcsURI, "gs://")
+	if !ok {
+		return nil, fmt.Errorf("ocr: invalid GCS URI %q", gcsURI)
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("ocr: invalid GCS URI %q", gcsURI)
+	}
+
+	reader, err := w.gcs.Bucket(parts[0]).Object(parts[1]).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ocr: open GCS object: %w", err)
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/stellar/torifune/backend/internal/model"
+)
+
+// ErrSchemaNotFound is returned when an extraction schema is not found.
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// SchemaRepository defines the interface for extraction schema CRUD
+// operations.
+type SchemaRepository interface {
+	// GetByID retrieves a schema by its ID.
+	GetByID(ctx context.Context, id string) (*model.ExtractionSchema, error)
+
+	// ListByOrganization retrieves all schemas defined for an organization.
+	ListByOrganization(ctx context.Context, organizationID string) ([]model.ExtractionSchema, error)
+
+	// Create persists a new schema, assigning it an ID.
+	Create(ctx context.Context, schema *model.ExtractionSchema) error
+
+	// Update persists changes to an existing schema.
+	Update(ctx context.Context, schema *model.ExtractionSchema) error
+
+	// Delete removes a schema by its ID.
+	Delete(ctx context.Context, id string) error
+}
+
+// FirestoreSchemaRepository implements SchemaRepository using Firestore.
+type FirestoreSchemaRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreSchemaRepository creates a new FirestoreSchemaRepository.
+func NewFirestoreSchemaRepository(client *firestore.Client) *FirestoreSchemaRepository {
+	return &FirestoreSchemaRepository{
+		client:     client,
+		collection: "extraction_schemas",
+	}
+}
+
+// GetByID retrieves a schema by its ID.
+func (r *FirestoreSchemaRepository) GetByID(ctx context.Context, id string) (*model.ExtractionSchema, error) {
+	doc, err := r.client.Collection(r.collection).Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrSchemaNotFound
+		}
+		return nil, err
+	}
+
+	var schema model.ExtractionSchema
+	if err := doc.DataTo(&schema); err != nil {
+		return nil, err
+	}
+
+	return &schema, nil
+}
+
+// ListByOrganization retrieves all schemas defined for an organization.
+func (r *FirestoreSchemaRepository) ListByOrganization(ctx context.Context, organizationID string) ([]model.ExtractionSchema, error) {
+	docs, err := r.client.Collection(r.collection).
+		Where("organizationId", "==", organizationID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make([]model.ExtractionSchema, 0, len(docs))
+	for _, doc := range docs {
+		var schema model.ExtractionSchema
+		if err := doc.DataTo(&schema); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, nil
+}
+
+// Create persists a new schema, assigning it an ID.
+func (r *FirestoreSchemaRepository) Create(ctx context.Context, schema *model.ExtractionSchema) error {
+	schema.ID = uuid.New().String()
+	now := time.Now()
+	schema.CreatedAt = now
+	schema.UpdatedAt = now
+
+	_, err := r.client.Collection(r.collection).Doc(schema.ID).Set(ctx, schema)
+	return err
+}
+
+// Update persists changes to an existing schema.
+func (r *FirestoreSchemaRepository) Update(ctx context.Context, schema *model.ExtractionSchema) error {
+	schema.UpdatedAt = time.Now()
+
+	_, err := r.client.Collection(r.collection).Doc(schema.ID).Set(ctx, schema)
+	return err
+}
+
+// Delete removes a schema by its ID.
+func (r *FirestoreSchemaRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.client.Collection(r.collection).Doc(id).Delete(ctx)
+	return err
+}
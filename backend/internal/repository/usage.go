@@ -24,23 +24,48 @@ type UsageRepository interface {
 	// IncrementCount increments the processed count for the current month.
 	IncrementCount(ctx context.Context, entityID, entityType string, count int) error
 
+	// ReserveQuota atomically checks the current month's usage against limit
+	// and, if there's room, reserves count units by incrementing
+	// ProcessedCount, all within a single transaction. unlimited bypasses the
+	// limit check but still increments, so usage history stays accurate. It
+	// returns ok=false (with a nil error) when the reservation would exceed
+	// limit, so callers can distinguish "quota exceeded" from a transport
+	// failure.
+	ReserveQuota(ctx context.Context, entityID, entityType string, count, limit int, unlimited bool) (ok bool, remaining int, err error)
+
+	// ReleaseQuota rolls back count units previously reserved via
+	// ReserveQuota, e.g. when OCR processing fails after quota was reserved
+	// for it.
+	ReleaseQuota(ctx context.Context, entityID, entityType string, count int) error
+
 	// GetHistory retrieves usage history for the specified number of months.
 	GetHistory(ctx context.Context, entityID string, months int) ([]model.MonthlyUsage, error)
+
+	// ReserveDailyQuota is ReserveQuota's per-user, per-day counterpart, used
+	// by usage.PolicyEngine to enforce a daily cap on a single user
+	// independent of their organization's monthly usage.
+	ReserveDailyQuota(ctx context.Context, userID string, count, limit int) (ok bool, remaining int, err error)
+
+	// ReleaseDailyQuota rolls back count units previously reserved via
+	// ReserveDailyQuota.
+	ReleaseDailyQuota(ctx context.Context, userID string, count int) error
 }
 
 // FirestoreUsageRepository implements UsageRepository using Firestore.
 type FirestoreUsageRepository struct {
-	client       *firestore.Client
-	collection   string
-	freeTierLimit int
+	client          *firestore.Client
+	collection      string
+	dailyCollection string
+	freeTierLimit   int
 }
 
 // NewFirestoreUsageRepository creates a new FirestoreUsageRepository.
 func NewFirestoreUsageRepository(client *firestore.Client, freeTierLimit int) *FirestoreUsageRepository {
 	return &FirestoreUsageRepository{
-		client:       client,
-		collection:   "monthly_usage",
-		freeTierLimit: freeTierLimit,
+		client:          client,
+		collection:      "monthly_usage",
+		dailyCollection: "daily_usage",
+		freeTierLimit:   freeTierLimit,
 	}
 }
 
@@ -119,6 +144,84 @@ func (r *FirestoreUsageRepository) IncrementCount(ctx context.Context, entityID,
 	})
 }
 
+// ReserveQuota implements UsageRepository.
+func (r *FirestoreUsageRepository) ReserveQuota(ctx context.Context, entityID, entityType string, count, limit int, unlimited bool) (ok bool, remaining int, err error) {
+	yearMonth := currentYearMonth()
+	docID := r.documentID(entityID, entityType, yearMonth)
+	docRef := r.client.Collection(r.collection).Doc(docID)
+
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, getErr := tx.Get(docRef)
+
+		var usage model.MonthlyUsage
+		switch {
+		case getErr == nil:
+			if err := doc.DataTo(&usage); err != nil {
+				return err
+			}
+		case status.Code(getErr) == codes.NotFound:
+			usage = model.MonthlyUsage{
+				EntityID:   entityID,
+				EntityType: entityType,
+				YearMonth:  yearMonth,
+			}
+		default:
+			return getErr
+		}
+
+		if !unlimited && usage.ProcessedCount+count > limit {
+			ok = false
+			remaining = limit - usage.ProcessedCount
+			if remaining < 0 {
+				remaining = 0
+			}
+			return nil
+		}
+
+		usage.ProcessedCount += count
+		usage.FreeLimit = limit
+		usage.UpdatedAt = time.Now()
+
+		ok = true
+		remaining = limit - usage.ProcessedCount
+
+		return tx.Set(docRef, usage)
+	})
+
+	return ok, remaining, err
+}
+
+// ReleaseQuota implements UsageRepository.
+func (r *FirestoreUsageRepository) ReleaseQuota(ctx context.Context, entityID, entityType string, count int) error {
+	yearMonth := currentYearMonth()
+	docID := r.documentID(entityID, entityType, yearMonth)
+	docRef := r.client.Collection(r.collection).Doc(docID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				// Nothing was ever reserved; nothing to release.
+				return nil
+			}
+			return err
+		}
+
+		var usage model.MonthlyUsage
+		if err := doc.DataTo(&usage); err != nil {
+			return err
+		}
+
+		usage.ProcessedCount -= count
+		if usage.ProcessedCount < 0 {
+			usage.ProcessedCount = 0
+		}
+		usage.UpdatedAt = time.Now()
+
+		return tx.Set(docRef, usage)
+	})
+}
+
 // GetHistory retrieves usage history for the specified number of months.
 func (r *FirestoreUsageRepository) GetHistory(ctx context.Context, entityID string, months int) ([]model.MonthlyUsage, error) {
 	// Calculate the year-months we need to query
@@ -151,3 +254,79 @@ func (r *FirestoreUsageRepository) GetHistory(ctx context.Context, entityID stri
 
 	return usages, nil
 }
+
+// currentDay returns today's date in "YYYYMMDD" format.
+func currentDay() string {
+	return time.Now().Format("20060102")
+}
+
+// ReserveDailyQuota implements UsageRepository.
+func (r *FirestoreUsageRepository) ReserveDailyQuota(ctx context.Context, userID string, count, limit int) (ok bool, remaining int, err error) {
+	day := currentDay()
+	docID := fmt.Sprintf("%s_%s", userID, day)
+	docRef := r.client.Collection(r.dailyCollection).Doc(docID)
+
+	err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, getErr := tx.Get(docRef)
+
+		var usage model.DailyUsage
+		switch {
+		case getErr == nil:
+			if err := doc.DataTo(&usage); err != nil {
+				return err
+			}
+		case status.Code(getErr) == codes.NotFound:
+			usage = model.DailyUsage{UserID: userID, Day: day}
+		default:
+			return getErr
+		}
+
+		if usage.ProcessedCount+count > limit {
+			ok = false
+			remaining = limit - usage.ProcessedCount
+			if remaining < 0 {
+				remaining = 0
+			}
+			return nil
+		}
+
+		usage.ProcessedCount += count
+		usage.UpdatedAt = time.Now()
+
+		ok = true
+		remaining = limit - usage.ProcessedCount
+
+		return tx.Set(docRef, usage)
+	})
+
+	return ok, remaining, err
+}
+
+// ReleaseDailyQuota implements UsageRepository.
+func (r *FirestoreUsageRepository) ReleaseDailyQuota(ctx context.Context, userID string, count int) error {
+	docID := fmt.Sprintf("%s_%s", userID, currentDay())
+	docRef := r.client.Collection(r.dailyCollection).Doc(docID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		}
+
+		var usage model.DailyUsage
+		if err := doc.DataTo(&usage); err != nil {
+			return err
+		}
+
+		usage.ProcessedCount -= count
+		if usage.ProcessedCount < 0 {
+			usage.ProcessedCount = 0
+		}
+		usage.UpdatedAt = time.Now()
+
+		return tx.Set(docRef, usage)
+	})
+}
@@ -23,6 +23,11 @@ type UserRepository interface {
 	// GetByProviderID retrieves a user by their OAuth provider and provider-specific ID.
 	GetByProviderID(ctx context.Context, provider, providerID string) (*model.User, error)
 
+	// GetByWorkspaceID retrieves the user who authorized a Slack workspace
+	// (i.e. whose bot/user tokens are stored for it) by that workspace's
+	// Slack team ID.
+	GetByWorkspaceID(ctx context.Context, workspaceID string) (*model.User, error)
+
 	// Create creates a new user in the database.
 	Create(ctx context.Context, user *model.User) error
 
@@ -86,6 +91,30 @@ func (r *FirestoreUserRepository) GetByProviderID(ctx context.Context, provider,
 	return &user, nil
 }
 
+// GetByWorkspaceID retrieves the user who authorized a Slack workspace by
+// that workspace's Slack team ID.
+func (r *FirestoreUserRepository) GetByWorkspaceID(ctx context.Context, workspaceID string) (*model.User, error) {
+	query := r.client.Collection(r.collection).
+		Where("workspaceId", "==", workspaceID).
+		Limit(1)
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(docs) == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	var user model.User
+	if err := docs[0].DataTo(&user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 // Create creates a new user in the database.
 func (r *FirestoreUserRepository) Create(ctx context.Context, user *model.User) error {
 	now := time.Now()
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -13,9 +15,39 @@ import (
 )
 
 const (
-	googleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
+	googleUserInfoURL    = "https://www.googleapis.com/oauth2/v2/userinfo"
+	googleDeviceAuthURL  = "https://oauth2.googleapis.com/device/code"
+	googleDeviceTokenURL = "https://oauth2.googleapis.com/token"
+
+	// googleDeviceGrantType is the grant_type value for RFC 8628 device-code
+	// token polling.
+	googleDeviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// googleIssuer is the "iss" claim Google's id_tokens carry; also the
+	// base URL OIDCVerifier discovers Google's JWKS from.
+	googleIssuer = "https://accounts.google.com"
 )
 
+// googleDeviceAuthResponse is Google's response from /device/code.
+type googleDeviceAuthResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURL         string `json:"verification_url"`
+	VerificationURLComplete string `json:"verification_url_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// googleDeviceTokenResponse is Google's response from polling /token with
+// the device-code grant; Error is populated instead of the token fields
+// while the user hasn't finished verifying.
+type googleDeviceTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
 // googleUserInfoResponse represents the response from Google's userinfo endpoint.
 type googleUserInfoResponse struct {
 	ID            string `json:"id"`
@@ -30,11 +62,21 @@ type googleUserInfoResponse struct {
 
 // GoogleProvider implements the OAuthProvider interface for Google OAuth.
 type GoogleProvider struct {
-	config *oauth2.Config
+	config        *oauth2.Config
+	oidcVerifier  *OIDCVerifier
+	deviceAuthURL string
 }
 
-// NewGoogleProvider creates a new Google OAuth provider.
-func NewGoogleProvider(clientID, clientSecret, redirectURL string) *GoogleProvider {
+// NewGoogleProvider creates a new Google OAuth provider. emailPolicy
+// controls whether GetUserInfoFromToken rejects an id_token whose email
+// isn't verified. deviceAuthURL overrides the device-authorization endpoint
+// DeviceAuth posts to (GOOGLE_DEVICE_AUTH_ENDPOINT); leaving it empty uses
+// Google's standard endpoint.
+func NewGoogleProvider(clientID, clientSecret, redirectURL string, emailPolicy EmailVerificationPolicy, deviceAuthURL string) *GoogleProvider {
+	if deviceAuthURL == "" {
+		deviceAuthURL = googleDeviceAuthURL
+	}
+
 	return &GoogleProvider{
 		config: &oauth2.Config{
 			ClientID:     clientID,
@@ -47,11 +89,13 @@ func NewGoogleProvider(clientID, clientSecret, redirectURL string) *GoogleProvid
 			},
 			Endpoint: google.Endpoint,
 		},
+		oidcVerifier:  NewOIDCVerifier(googleIssuer, clientID, emailPolicy),
+		deviceAuthURL: deviceAuthURL,
 	}
 }
 
 // GetAuthURL returns the Google OAuth authorization URL.
-func (p *GoogleProvider) GetAuthURL(state, codeChallenge string) string {
+func (p *GoogleProvider) GetAuthURL(state, codeChallenge, nonce string) string {
 	opts := []oauth2.AuthCodeOption{
 		oauth2.AccessTypeOffline,
 		oauth2.SetAuthURLParam("prompt", "consent"),
@@ -65,6 +109,10 @@ func (p *GoogleProvider) GetAuthURL(state, codeChallenge string) string {
 		)
 	}
 
+	if nonce != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("nonce", nonce))
+	}
+
 	return p.config.AuthCodeURL(state, opts...)
 }
 
@@ -82,10 +130,34 @@ func (p *GoogleProvider) ExchangeCode(ctx context.Context, code, codeVerifier st
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
+	idToken, _ := token.Extra("id_token").(string)
+
 	return &OAuthToken{
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
 		ExpiresAt:    token.Expiry,
+		IDToken:      idToken,
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new Google access token.
+func (p *GoogleProvider) RefreshToken(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	token, err := p.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	// Google only returns a new refresh token if one was revoked and
+	// reissued; otherwise the original keeps working.
+	newRefreshToken := token.RefreshToken
+	if newRefreshToken == "" {
+		newRefreshToken = refreshToken
+	}
+
+	return &OAuthToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresAt:    token.Expiry,
 	}, nil
 }
 
@@ -123,5 +195,118 @@ func (p *GoogleProvider) GetUserInfo(ctx context.Context, accessToken string) (*
 	}, nil
 }
 
-// Ensure GoogleProvider implements OAuthProvider.
+// GetUserInfoFromToken implements OIDCUserInfoProvider: it verifies
+// token.IDToken against Google's JWKS instead of making the extra call
+// GetUserInfo makes, falling back to GetUserInfo when there's no id_token
+// to verify (e.g. a caller that didn't request the "openid" scope).
+func (p *GoogleProvider) GetUserInfoFromToken(ctx context.Context, token *OAuthToken, expectedNonce string) (*UserInfo, error) {
+	if token.IDToken == "" {
+		return p.GetUserInfo(ctx, token.AccessToken)
+	}
+	return p.oidcVerifier.Verify(ctx, token.IDToken, expectedNonce)
+}
+
+// DeviceAuth begins the OAuth 2.0 Device Authorization Grant (RFC 8628)
+// flow, for terminal apps and desktop tools that can't receive a redirect.
+func (p *GoogleProvider) DeviceAuth(ctx context.Context) (*DeviceAuthResponse, error) {
+	data := url.Values{}
+	data.Set("client_id", p.config.ClientID)
+	data.Set("scope", strings.Join(p.config.Scopes, " "))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.deviceAuthURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var deviceResp googleDeviceAuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deviceResp); err != nil {
+		return nil, fmt.Errorf("failed to decode device auth response: %w", err)
+	}
+
+	return &DeviceAuthResponse{
+		DeviceCode:              deviceResp.DeviceCode,
+		UserCode:                deviceResp.UserCode,
+		VerificationURI:         deviceResp.VerificationURL,
+		VerificationURIComplete: deviceResp.VerificationURLComplete,
+		ExpiresIn:               deviceResp.ExpiresIn,
+		Interval:                deviceResp.Interval,
+	}, nil
+}
+
+// PollDeviceToken makes a single poll of Google's token endpoint for
+// deviceCode. See DeviceAuthProvider for the expected polling behavior.
+func (p *GoogleProvider) PollDeviceToken(ctx context.Context, deviceCode string) (*OAuthToken, error) {
+	data := url.Values{}
+	data.Set("client_id", p.config.ClientID)
+	data.Set("client_secret", p.config.ClientSecret)
+	data.Set("device_code", deviceCode)
+	data.Set("grant_type", googleDeviceGrantType)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleDeviceTokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll device token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResp googleDeviceTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode device token response: %w", err)
+	}
+
+	if tokenResp.Error != "" {
+		if err := deviceErrorFor(tokenResp.Error); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("device token error: %s", tokenResp.Error)
+	}
+
+	return &OAuthToken{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// deviceErrorFor maps an RFC 8628 token-endpoint error code to the matching
+// sentinel error, or nil if code isn't one of the ones callers branch on.
+func deviceErrorFor(code string) error {
+	switch code {
+	case "authorization_pending":
+		return ErrAuthorizationPending
+	case "slow_down":
+		return ErrSlowDown
+	case "expired_token":
+		return ErrDeviceCodeExpired
+	case "access_denied":
+		return ErrDeviceAccessDenied
+	default:
+		return nil
+	}
+}
+
+// Ensure GoogleProvider implements OAuthProvider, TokenRefreshingProvider,
+// DeviceAuthProvider, and OIDCUserInfoProvider.
 var _ OAuthProvider = (*GoogleProvider)(nil)
+var _ TokenRefreshingProvider = (*GoogleProvider)(nil)
+var _ DeviceAuthProvider = (*GoogleProvider)(nil)
+var _ OIDCUserInfoProvider = (*GoogleProvider)(nil)
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// JWKSHandler serves the public half of a KeySet as a standard JWKS document,
+// so other services can validate tokens this service issues (via
+// JWTService, signed with the same KeySet) without sharing a secret.
+type JWKSHandler struct {
+	mu     sync.RWMutex
+	keySet *KeySet
+}
+
+// NewJWKSHandler creates a JWKSHandler serving keySet's public keys.
+func NewJWKSHandler(keySet *KeySet) *JWKSHandler {
+	return &JWKSHandler{keySet: keySet}
+}
+
+// SetKeySet swaps the KeySet served, so it stays in sync with a JWTService
+// that had its own keys rotated via JWTService.SetKeySet.
+func (h *JWKSHandler) SetKeySet(keySet *KeySet) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keySet = keySet
+}
+
+// ServeHTTP writes the JWKS document as JSON. Intended to be mounted at the
+// conventional "/.well-known/jwks.json" path.
+func (h *JWKSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	keySet := h.keySet
+	h.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keySet.JWKS())
+}
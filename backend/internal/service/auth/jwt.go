@@ -1,11 +1,14 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 const (
@@ -30,6 +33,12 @@ var (
 	ErrInvalidToken     = errors.New("invalid token")
 	ErrExpiredToken     = errors.New("token has expired")
 	ErrInvalidTokenType = errors.New("invalid token type")
+	ErrRevokedToken     = errors.New("token has been revoked")
+	// ErrRefreshTokenReused is returned by RefreshTokens when the presented
+	// refresh token's jti was already consumed by an earlier refresh: its
+	// whole family is revoked before returning, so every other outstanding
+	// token from that login is rejected too.
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
 )
 
 // Claims represents the custom claims for JWT tokens.
@@ -37,42 +46,96 @@ type Claims struct {
 	jwt.RegisteredClaims
 	UserID    string    `json:"user_id"`
 	TokenType TokenType `json:"token_type"`
+	// FamilyID groups every refresh token descended from the same login
+	// (see GenerateTokens/RefreshTokens), so a stolen-and-replayed refresh
+	// token can take down the rest of that session. Only set on refresh
+	// tokens.
+	FamilyID string `json:"family_id,omitempty"`
 }
 
 // JWTService handles JWT token generation and validation.
 type JWTService struct {
-	secretKey []byte
-	issuer    string
+	mu                sync.RWMutex
+	keySet            *KeySet
+	issuer            string
+	revocationStore   TokenRevocationStore
+	refreshTokenStore RefreshTokenStore
 }
 
-// NewJWTService creates a new JWT service.
-func NewJWTService(secretKey, issuer string) *JWTService {
+// NewJWTService creates a new JWT service. Tokens are signed with keySet's
+// primary key and carry its kid in their header; ValidateToken looks the kid
+// back up in keySet, so tokens signed with a previous key (kept around
+// during a rotation window) still validate. revocationStore may be nil, in
+// which case RevokeToken is a no-op and ValidateToken never rejects a token
+// as revoked. refreshTokenStore may also be nil, in which case RefreshTokens
+// rotates tokens without reuse detection (e.g. for a deployment that hasn't
+// configured one yet).
+func NewJWTService(keySet *KeySet, issuer string, revocationStore TokenRevocationStore, refreshTokenStore RefreshTokenStore) *JWTService {
 	return &JWTService{
-		secretKey: []byte(secretKey),
-		issuer:    issuer,
+		keySet:            keySet,
+		issuer:            issuer,
+		revocationStore:   revocationStore,
+		refreshTokenStore: refreshTokenStore,
 	}
 }
 
-// GenerateTokens generates both access and refresh tokens for a user.
-func (s *JWTService) GenerateTokens(userID string) (accessToken, refreshToken string, err error) {
-	accessToken, err = s.generateToken(userID, TokenTypeAccess, accessTokenExpiry)
+// SetKeySet swaps the signing/verification key set in place, so a rotated
+// or re-resolved key (see config.Watch) takes effect without restarting
+// the process. Safe for concurrent use with GenerateTokens/ValidateToken.
+func (s *JWTService) SetKeySet(keySet *KeySet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keySet = keySet
+}
+
+// currentKeySet returns the key set in effect, guarding against a
+// concurrent SetKeySet.
+func (s *JWTService) currentKeySet() *KeySet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keySet
+}
+
+// GenerateTokens generates both access and refresh tokens for a user,
+// starting a new refresh-token family. Each token gets its own "jti"
+// (Claims.ID), so either can be revoked individually via RevokeToken
+// without affecting the other.
+func (s *JWTService) GenerateTokens(ctx context.Context, userID string) (accessToken, refreshToken string, err error) {
+	return s.generateTokenPair(ctx, userID, uuid.New().String())
+}
+
+// generateTokenPair mints a new access/refresh pair for userID, with the
+// refresh token belonging to familyID, and (if a refresh-token store is
+// configured) registers the refresh token's jti so a later reuse can be
+// detected.
+func (s *JWTService) generateTokenPair(ctx context.Context, userID, familyID string) (accessToken, refreshToken string, err error) {
+	accessToken, _, err = s.generateToken(userID, TokenTypeAccess, accessTokenExpiry, "")
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err = s.generateToken(userID, TokenTypeRefresh, refreshTokenExpiry)
+	refreshToken, refreshJTI, err := s.generateToken(userID, TokenTypeRefresh, refreshTokenExpiry, familyID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
+	if s.refreshTokenStore != nil {
+		if err := s.refreshTokenStore.Register(ctx, userID, familyID, refreshJTI, refreshTokenExpiry); err != nil {
+			return "", "", fmt.Errorf("register refresh token: %w", err)
+		}
+	}
+
 	return accessToken, refreshToken, nil
 }
 
-// generateToken creates a JWT token with the specified parameters.
-func (s *JWTService) generateToken(userID string, tokenType TokenType, expiry time.Duration) (string, error) {
+// generateToken creates a JWT token with the specified parameters, and
+// returns the jti it was issued with alongside the signed token.
+func (s *JWTService) generateToken(userID string, tokenType TokenType, expiry time.Duration, familyID string) (token string, jti string, err error) {
 	now := time.Now()
+	jti = uuid.New().String()
 	claims := &Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			Issuer:    s.issuer,
 			Subject:   userID,
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -81,20 +144,34 @@ func (s *JWTService) generateToken(userID string, tokenType TokenType, expiry ti
 		},
 		UserID:    userID,
 		TokenType: tokenType,
+		FamilyID:  familyID,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	signingKey := s.currentKeySet().Primary()
+	jwtToken := jwt.NewWithClaims(signingKey.Method(), claims)
+	jwtToken.Header["kid"] = signingKey.Kid()
+
+	signed, err := jwtToken.SignedString(signingKey.SignKey())
+	if err != nil {
+		return "", "", err
+	}
+	return signed, jti, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims.
-func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken validates a JWT token and returns the claims. If a
+// revocation store is configured, a token whose jti was revoked (via
+// RevokeToken) is rejected even though its signature and expiry are fine.
+func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method.
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		signingKey, ok := s.currentKeySet().Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if token.Method.Alg() != signingKey.Method().Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return s.secretKey, nil
+		return signingKey.VerifyKey(), nil
 	})
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -108,12 +185,22 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	if s.revocationStore != nil {
+		revoked, err := s.revocationStore.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+	}
+
 	return claims, nil
 }
 
 // ValidateAccessToken validates an access token.
-func (s *JWTService) ValidateAccessToken(tokenString string) (*Claims, error) {
-	claims, err := s.ValidateToken(tokenString)
+func (s *JWTService) ValidateAccessToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := s.ValidateToken(ctx, tokenString)
 	if err != nil {
 		return nil, err
 	}
@@ -126,8 +213,8 @@ func (s *JWTService) ValidateAccessToken(tokenString string) (*Claims, error) {
 }
 
 // ValidateRefreshToken validates a refresh token.
-func (s *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	claims, err := s.ValidateToken(tokenString)
+func (s *JWTService) ValidateRefreshToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := s.ValidateToken(ctx, tokenString)
 	if err != nil {
 		return nil, err
 	}
@@ -139,14 +226,86 @@ func (s *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-// RefreshTokens validates a refresh token and generates new access and refresh tokens.
-func (s *JWTService) RefreshTokens(refreshToken string) (accessToken, newRefreshToken string, err error) {
-	claims, err := s.ValidateRefreshToken(refreshToken)
+// RefreshTokens validates a refresh token and generates new access and
+// refresh tokens, rotating the refresh token's family forward. If a
+// refresh-token store is configured and claims.ID (jti) was already
+// consumed by an earlier refresh, the whole family is revoked and
+// ErrRefreshTokenReused is returned instead, since that only happens when a
+// refresh token has been stolen and used by two different parties.
+func (s *JWTService) RefreshTokens(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, err error) {
+	claims, err := s.ValidateRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return "", "", fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	return s.GenerateTokens(claims.UserID)
+	if s.refreshTokenStore != nil {
+		status, err := s.refreshTokenStore.Consume(ctx, claims.FamilyID, claims.ID)
+		if err != nil {
+			return "", "", fmt.Errorf("check refresh token reuse: %w", err)
+		}
+		switch status {
+		case RefreshTokenActive:
+			// Expected case: proceed to rotate.
+		case RefreshTokenConsumed:
+			if err := s.refreshTokenStore.RevokeFamily(ctx, claims.FamilyID); err != nil {
+				return "", "", fmt.Errorf("revoke reused refresh token family: %w", err)
+			}
+			return "", "", ErrRefreshTokenReused
+		case RefreshTokenFamilyRevoked:
+			return "", "", ErrRevokedToken
+		default:
+			return "", "", ErrInvalidToken
+		}
+	}
+
+	return s.generateTokenPair(ctx, claims.UserID, claims.FamilyID)
+}
+
+// RevokeAllUserSessions revokes every refresh-token family belonging to
+// userID, so every device currently logged in is forced to re-authenticate
+// (logout-all-devices). It's a no-op if no refresh-token store is
+// configured.
+func (s *JWTService) RevokeAllUserSessions(ctx context.Context, userID string) error {
+	if s.refreshTokenStore == nil {
+		return nil
+	}
+	return s.refreshTokenStore.RevokeUser(ctx, userID)
+}
+
+// RevokeRefreshFamily revokes every refresh token descended from familyID,
+// forcing re-authentication for that one login session without affecting
+// the user's other sessions. It's a no-op if no refresh-token store is
+// configured.
+func (s *JWTService) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	if s.refreshTokenStore == nil {
+		return nil
+	}
+	return s.refreshTokenStore.RevokeFamily(ctx, familyID)
+}
+
+// RevokeToken records tokenString's jti as revoked for the remainder of its
+// natural lifetime, so ValidateToken rejects it even though it hasn't
+// expired yet. It's a no-op if no revocation store is configured, or if
+// tokenString is already expired (nothing left to revoke).
+func (s *JWTService) RevokeToken(ctx context.Context, tokenString string) error {
+	if s.revocationStore == nil {
+		return nil
+	}
+
+	claims, err := s.ValidateToken(ctx, tokenString)
+	if err != nil {
+		if errors.Is(err, ErrExpiredToken) {
+			return nil
+		}
+		return fmt.Errorf("parse token to revoke: %w", err)
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.revocationStore.Revoke(ctx, claims.ID, ttl)
 }
 
 // GetAccessTokenExpiry returns the access token expiration duration.
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -14,8 +16,31 @@ import (
 const (
 	// Microsoft Graph API endpoint for user info.
 	microsoftGraphUserInfoURL = "https://graph.microsoft.com/v1.0/me"
+
+	// microsoftDeviceGrantType is the grant_type value for RFC 8628
+	// device-code token polling.
+	microsoftDeviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
 )
 
+// microsoftDeviceAuthResponse is Azure AD's response from /devicecode.
+type microsoftDeviceAuthResponse struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// microsoftDeviceTokenResponse is Azure AD's response from polling /token
+// with the device-code grant; Error is populated instead of the token
+// fields while the user hasn't finished verifying.
+type microsoftDeviceTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
 // microsoftUserInfoResponse represents the response from Microsoft Graph API.
 type microsoftUserInfoResponse struct {
 	ID                string `json:"id"`
@@ -28,17 +53,35 @@ type microsoftUserInfoResponse struct {
 
 // MicrosoftProvider implements the OAuthProvider interface for Microsoft (Azure AD) OAuth.
 type MicrosoftProvider struct {
-	config   *oauth2.Config
-	tenantID string
+	config        *oauth2.Config
+	tenantID      string
+	oidcVerifier  *OIDCVerifier
+	deviceAuthURL string
 }
 
-// NewMicrosoftProvider creates a new Microsoft OAuth provider.
-func NewMicrosoftProvider(clientID, clientSecret, tenantID, redirectURL string) *MicrosoftProvider {
+// NewMicrosoftProvider creates a new Microsoft OAuth provider. emailPolicy
+// controls whether GetUserInfoFromToken rejects an id_token whose email
+// isn't verified. deviceAuthURL overrides the device-authorization endpoint
+// DeviceAuth posts to (MICROSOFT_DEVICE_AUTH_ENDPOINT); leaving it empty
+// builds the standard per-tenant endpoint from tenantID.
+func NewMicrosoftProvider(clientID, clientSecret, tenantID, redirectURL string, emailPolicy EmailVerificationPolicy, deviceAuthURL string) *MicrosoftProvider {
 	// Use "common" for multi-tenant apps, or specific tenant ID for single-tenant.
 	if tenantID == "" {
 		tenantID = "common"
 	}
 
+	if deviceAuthURL == "" {
+		deviceAuthURL = fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/devicecode", tenantID)
+	}
+
+	// A real id_token's "iss" claim is always the signed-in user's actual
+	// tenant GUID, never the literal "common"/"organizations" alias, so
+	// GetUserInfoFromToken's verification only succeeds for a specific
+	// tenantID. Multi-tenant deployments that need id_token verification
+	// should configure one; until then, a present id_token simply fails
+	// closed rather than silently falling back to the userinfo endpoint.
+	oidcIssuer := fmt.Sprintf("https://login.microsoftonline.com/%s/v2.0", tenantID)
+
 	return &MicrosoftProvider{
 		config: &oauth2.Config{
 			ClientID:     clientID,
@@ -55,12 +98,14 @@ func NewMicrosoftProvider(clientID, clientSecret, tenantID, redirectURL string)
 				TokenURL: fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", tenantID),
 			},
 		},
-		tenantID: tenantID,
+		tenantID:      tenantID,
+		oidcVerifier:  NewOIDCVerifier(oidcIssuer, clientID, emailPolicy),
+		deviceAuthURL: deviceAuthURL,
 	}
 }
 
 // GetAuthURL returns the Microsoft OAuth authorization URL.
-func (p *MicrosoftProvider) GetAuthURL(state, codeChallenge string) string {
+func (p *MicrosoftProvider) GetAuthURL(state, codeChallenge, nonce string) string {
 	opts := []oauth2.AuthCodeOption{
 		oauth2.AccessTypeOffline,
 		oauth2.SetAuthURLParam("prompt", "consent"),
@@ -74,6 +119,10 @@ func (p *MicrosoftProvider) GetAuthURL(state, codeChallenge string) string {
 		)
 	}
 
+	if nonce != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("nonce", nonce))
+	}
+
 	return p.config.AuthCodeURL(state, opts...)
 }
 
@@ -91,10 +140,34 @@ func (p *MicrosoftProvider) ExchangeCode(ctx context.Context, code, codeVerifier
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
+	idToken, _ := token.Extra("id_token").(string)
+
 	return &OAuthToken{
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
 		ExpiresAt:    token.Expiry,
+		IDToken:      idToken,
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new Microsoft access token.
+func (p *MicrosoftProvider) RefreshToken(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	token, err := p.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	// Azure AD always rotates the refresh token; fall back to the original
+	// just in case a future response omits it.
+	newRefreshToken := token.RefreshToken
+	if newRefreshToken == "" {
+		newRefreshToken = refreshToken
+	}
+
+	return &OAuthToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresAt:    token.Expiry,
 	}, nil
 }
 
@@ -141,5 +214,100 @@ func (p *MicrosoftProvider) GetUserInfo(ctx context.Context, accessToken string)
 	}, nil
 }
 
-// Ensure MicrosoftProvider implements OAuthProvider.
+// GetUserInfoFromToken implements OIDCUserInfoProvider: it verifies
+// token.IDToken against Azure AD's JWKS instead of making the extra call
+// GetUserInfo makes, falling back to GetUserInfo when there's no id_token
+// to verify (e.g. a caller that didn't request the "openid" scope).
+func (p *MicrosoftProvider) GetUserInfoFromToken(ctx context.Context, token *OAuthToken, expectedNonce string) (*UserInfo, error) {
+	if token.IDToken == "" {
+		return p.GetUserInfo(ctx, token.AccessToken)
+	}
+	return p.oidcVerifier.Verify(ctx, token.IDToken, expectedNonce)
+}
+
+// DeviceAuth begins the OAuth 2.0 Device Authorization Grant (RFC 8628)
+// flow, for terminal apps and desktop tools that can't receive a redirect.
+func (p *MicrosoftProvider) DeviceAuth(ctx context.Context) (*DeviceAuthResponse, error) {
+	data := url.Values{}
+	data.Set("client_id", p.config.ClientID)
+	data.Set("scope", strings.Join(p.config.Scopes, " "))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.deviceAuthURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var deviceResp microsoftDeviceAuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&deviceResp); err != nil {
+		return nil, fmt.Errorf("failed to decode device auth response: %w", err)
+	}
+
+	return &DeviceAuthResponse{
+		DeviceCode:              deviceResp.DeviceCode,
+		UserCode:                deviceResp.UserCode,
+		VerificationURI:         deviceResp.VerificationURI,
+		VerificationURIComplete: deviceResp.VerificationURI + "?otc=" + deviceResp.UserCode,
+		ExpiresIn:               deviceResp.ExpiresIn,
+		Interval:                deviceResp.Interval,
+	}, nil
+}
+
+// PollDeviceToken makes a single poll of Azure AD's token endpoint for
+// deviceCode. See DeviceAuthProvider for the expected polling behavior.
+func (p *MicrosoftProvider) PollDeviceToken(ctx context.Context, deviceCode string) (*OAuthToken, error) {
+	data := url.Values{}
+	data.Set("client_id", p.config.ClientID)
+	data.Set("device_code", deviceCode)
+	data.Set("grant_type", microsoftDeviceGrantType)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint.TokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll device token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResp microsoftDeviceTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode device token response: %w", err)
+	}
+
+	if tokenResp.Error != "" {
+		if err := deviceErrorFor(tokenResp.Error); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("device token error: %s", tokenResp.Error)
+	}
+
+	return &OAuthToken{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// Ensure MicrosoftProvider implements OAuthProvider, TokenRefreshingProvider,
+// DeviceAuthProvider, and OIDCUserInfoProvider.
 var _ OAuthProvider = (*MicrosoftProvider)(nil)
+var _ TokenRefreshingProvider = (*MicrosoftProvider)(nil)
+var _ DeviceAuthProvider = (*MicrosoftProvider)(nil)
+var _ OIDCUserInfoProvider = (*MicrosoftProvider)(nil)
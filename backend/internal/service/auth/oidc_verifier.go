@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcJWKSRefreshInterval bounds how long an OIDCVerifier's cached JWKS
+// keys are trusted before being re-fetched, so a rotated signing key (new
+// kid) is picked up without requiring a restart.
+const oidcJWKSRefreshInterval = 10 * time.Minute
+
+// EmailVerificationPolicy controls whether OIDCVerifier accepts an
+// id_token whose email_verified claim is false or absent.
+type EmailVerificationPolicy int
+
+const (
+	// RequireVerifiedEmail rejects an id_token whose email isn't verified.
+	RequireVerifiedEmail EmailVerificationPolicy = iota
+	// AllowUnverifiedEmail accepts the email claim regardless of
+	// email_verified.
+	AllowUnverifiedEmail
+)
+
+// oidcJWK and oidcJWKSDocument mirror middleware's jwk/jwksDocument types;
+// duplicated here rather than shared since they're unexported in that
+// package and an OIDCVerifier only ever needs a single issuer's keys, not
+// middleware.AuthConfig's list of externally-trusted ones.
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type oidcJWKSDocument struct {
+	Keys []oidcJWK `json:"keys"`
+}
+
+// oidcDiscoveryDocument is the subset of an OIDC discovery document
+// (<issuer>/.well-known/openid-configuration) needed to locate the
+// issuer's JWKS endpoint.
+type oidcDiscoveryDocument struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// OIDCVerifier validates an OIDC id_token against a single issuer's
+// cached JWKS (located via OIDC discovery) and maps its claims into a
+// UserInfo, so a provider's GetUserInfoFromToken can skip an extra
+// userinfo-endpoint round trip when the token exchange already returned
+// an id_token.
+type OIDCVerifier struct {
+	issuer   string
+	clientID string
+	policy   EmailVerificationPolicy
+	client   *http.Client
+
+	mu        sync.Mutex
+	jwksURL   string // resolved lazily via OIDC discovery if empty
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewOIDCVerifier creates an OIDCVerifier for issuer/clientID. The JWKS
+// URL is discovered from issuer + "/.well-known/openid-configuration" on
+// first use.
+func NewOIDCVerifier(issuer, clientID string, policy EmailVerificationPolicy) *OIDCVerifier {
+	return &OIDCVerifier{
+		issuer:   issuer,
+		clientID: clientID,
+		policy:   policy,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Verify validates idToken's signature, iss, aud (against clientID), exp,
+// and nonce (against expectedNonce, if non-empty), then maps its claims
+// into a UserInfo. It rejects an unverified email per v.policy.
+func (v *OIDCVerifier) Verify(ctx context.Context, idToken, expectedNonce string) (*UserInfo, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("id_token has no kid header")
+		}
+		return v.key(ctx, kid)
+	}, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	iss, err := claims.GetIssuer()
+	if err != nil || iss != v.issuer {
+		return nil, fmt.Errorf("id_token issuer %q does not match expected %q", iss, v.issuer)
+	}
+
+	aud, err := claims.GetAudience()
+	if err != nil || !containsAudience(aud, v.clientID) {
+		return nil, fmt.Errorf("id_token audience does not match client ID")
+	}
+
+	if expectedNonce != "" {
+		nonce, _ := claims["nonce"].(string)
+		if nonce != expectedNonce {
+			return nil, fmt.Errorf("id_token nonce does not match expected value")
+		}
+	}
+
+	emailVerified, _ := claims["email_verified"].(bool)
+	if v.policy == RequireVerifiedEmail && !emailVerified {
+		return nil, fmt.Errorf("id_token email is not verified")
+	}
+
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	name, _ := claims["name"].(string)
+	picture, _ := claims["picture"].(string)
+
+	return &UserInfo{
+		ID:      sub,
+		Email:   email,
+		Name:    name,
+		Picture: picture,
+	}, nil
+}
+
+// containsAudience reports whether needle is present in haystack.
+func containsAudience(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the public key for kid, fetching or refreshing the
+// issuer's JWKS if it's missing or stale.
+func (v *OIDCVerifier) key(ctx context.Context, kid string) (interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok && time.Since(v.fetchedAt) < oidcJWKSRefreshInterval {
+		return key, nil
+	}
+
+	if err := v.refreshLocked(ctx); err != nil {
+		// A transient refresh error shouldn't take down a kid we already
+		// trust (e.g. a network blip mid-rotation); only surface the error
+		// for a kid we've never seen.
+		if key, ok := v.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc: issuer %q has no key for kid %q", v.issuer, kid)
+	}
+	return key, nil
+}
+
+// refreshLocked re-fetches and re-parses the issuer's JWKS. Callers must
+// hold v.mu.
+func (v *OIDCVerifier) refreshLocked(ctx context.Context) error {
+	jwksURL := v.jwksURL
+	if jwksURL == "" {
+		discovered, err := v.discoverJWKSURL(ctx)
+		if err != nil {
+			return fmt.Errorf("oidc: discover JWKS URL for issuer %q: %w", v.issuer, err)
+		}
+		jwksURL = discovered
+		v.jwksURL = discovered
+	}
+
+	doc, err := v.fetchJWKS(ctx, jwksURL)
+	if err != nil {
+		return fmt.Errorf("oidc: fetch %s: %w", jwksURL, err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		pubKey, err := parseOIDCJWK(k)
+		if err != nil {
+			// Skip keys Torifune doesn't understand (e.g. an unsupported
+			// kty) rather than failing the whole refresh over one entry.
+			continue
+		}
+		keys[k.Kid] = pubKey
+	}
+
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return nil
+}
+
+func (v *OIDCVerifier) discoverJWKSURL(ctx context.Context) (string, error) {
+	discoveryURL := strings.TrimSuffix(v.issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var doc oidcDiscoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("decode discovery document: %w", err)
+	}
+	if doc.JWKSURI == "" {
+		return "", fmt.Errorf("discovery document has no jwks_uri")
+	}
+	return doc.JWKSURI, nil
+}
+
+func (v *OIDCVerifier) fetchJWKS(ctx context.Context, jwksURL string) (*oidcJWKSDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var doc oidcJWKSDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+	return &doc, nil
+}
+
+// parseOIDCJWK reconstructs a crypto public key (*rsa.PublicKey or
+// *ecdsa.PublicKey) from a JWKS entry. Only the "RSA" and "EC" key types
+// are supported, which covers every OIDC provider Torifune targets.
+func parseOIDCJWK(k oidcJWK) (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(nBytes),
+			E: int(new(big.Int).SetBytes(eBytes).Int64()),
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+
+		xBytes, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		yBytes, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(xBytes),
+			Y:     new(big.Int).SetBytes(yBytes),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+}
@@ -3,6 +3,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -11,7 +12,10 @@ type OAuthProvider interface {
 	// GetAuthURL returns the authorization URL for the OAuth flow.
 	// state is used for CSRF protection.
 	// codeChallenge is used for PKCE (Proof Key for Code Exchange).
-	GetAuthURL(state, codeChallenge string) string
+	// nonce, if non-empty, is bound into the id_token for providers that
+	// return one (see OIDCUserInfoProvider), preventing id_token replay;
+	// providers that don't verify an id_token ignore it.
+	GetAuthURL(state, codeChallenge, nonce string) string
 
 	// ExchangeCode exchanges an authorization code for tokens.
 	// code is the authorization code received from the OAuth provider.
@@ -22,6 +26,100 @@ type OAuthProvider interface {
 	GetUserInfo(ctx context.Context, accessToken string) (*UserInfo, error)
 }
 
+// TokenRefreshingProvider is implemented by providers that can exchange a
+// refresh token for a new OAuthToken without involving the user. Not every
+// OAuthProvider needs to support this (e.g. providers only ever used for a
+// single userinfo lookup), so it's kept separate from OAuthProvider rather
+// than added as a required method.
+type TokenRefreshingProvider interface {
+	OAuthProvider
+
+	// RefreshToken exchanges a refresh token for a new OAuthToken. Providers
+	// that don't rotate the refresh token on every use may return the same
+	// RefreshToken value back; callers should not assume rotation.
+	RefreshToken(ctx context.Context, refreshToken string) (*OAuthToken, error)
+}
+
+// DeviceAuthProvider is implemented by providers that support the OAuth 2.0
+// Device Authorization Grant (RFC 8628) for browserless clients (CLIs, TVs).
+// Not every OAuthProvider exposes device endpoints (Slack doesn't), so it's
+// kept separate from OAuthProvider rather than added as a required method,
+// the same way TokenRefreshingProvider is.
+type DeviceAuthProvider interface {
+	OAuthProvider
+
+	// DeviceAuth begins the device flow, returning the codes and URIs the
+	// caller shows to the user.
+	DeviceAuth(ctx context.Context) (*DeviceAuthResponse, error)
+
+	// PollDeviceToken makes a single poll of the provider's token endpoint
+	// for deviceCode. Callers own the polling loop and should wait at least
+	// DeviceAuthResponse.Interval (bumped by 5s on ErrSlowDown, per the RFC)
+	// between calls.
+	PollDeviceToken(ctx context.Context, deviceCode string) (*OAuthToken, error)
+}
+
+// OIDCUserInfoProvider is implemented by providers that can verify an
+// OIDC id_token directly instead of round-tripping to a userinfo
+// endpoint. Not every OAuthProvider returns a verifiable id_token (Slack's
+// classic oauth.v2.access flow doesn't), so it's kept separate from
+// OAuthProvider rather than added as a required method, the same way
+// TokenRefreshingProvider is.
+type OIDCUserInfoProvider interface {
+	OAuthProvider
+
+	// GetUserInfoFromToken returns user info verified from token.IDToken
+	// against expectedNonce when IDToken is present, skipping the
+	// userinfo-endpoint call GetUserInfo makes. Falls back to GetUserInfo
+	// when token.IDToken is empty (e.g. the "openid" scope wasn't
+	// requested). A present but invalid id_token is a hard failure, not a
+	// silent fallback.
+	GetUserInfoFromToken(ctx context.Context, token *OAuthToken, expectedNonce string) (*UserInfo, error)
+}
+
+// DeviceAuthResponse is returned by DeviceAuthProvider.DeviceAuth.
+type DeviceAuthResponse struct {
+	// DeviceCode identifies this authorization session to PollDeviceToken.
+	DeviceCode string
+
+	// UserCode is the short code the user enters at VerificationURI.
+	UserCode string
+
+	// VerificationURI is where the user enters UserCode.
+	VerificationURI string
+
+	// VerificationURIComplete embeds UserCode in VerificationURI so the user
+	// (or a QR code) can skip typing it in.
+	VerificationURIComplete string
+
+	// ExpiresIn is how many seconds DeviceCode/UserCode remain valid.
+	ExpiresIn int
+
+	// Interval is the minimum number of seconds callers should wait between
+	// PollDeviceToken calls.
+	Interval int
+}
+
+// Device flow polling errors, per RFC 8628 section 3.5. Callers distinguish
+// these from a terminal failure: ErrAuthorizationPending and ErrSlowDown
+// mean "keep polling", the rest mean "stop".
+var (
+	// ErrAuthorizationPending means the user hasn't completed the
+	// verification step yet; the caller should poll again after Interval.
+	ErrAuthorizationPending = errors.New("authorization_pending")
+
+	// ErrSlowDown means the caller is polling too fast; it should add 5
+	// seconds to its polling interval and keep going.
+	ErrSlowDown = errors.New("slow_down")
+
+	// ErrDeviceCodeExpired means DeviceCode/UserCode expired before the user
+	// completed verification; the caller must start a new DeviceAuth flow.
+	ErrDeviceCodeExpired = errors.New("expired_token")
+
+	// ErrDeviceAccessDenied means the user explicitly declined the request.
+	ErrDeviceAccessDenied = errors.New("access_denied")
+)
+
 // OAuthToken represents the tokens received from an OAuth provider.
 type OAuthToken struct {
 	// AccessToken is used to authenticate API requests.
@@ -32,6 +130,16 @@ type OAuthToken struct {
 
 	// ExpiresAt is when the access token expires.
 	ExpiresAt time.Time
+
+	// BotAccessToken is a separate workspace-level token for providers that
+	// distinguish a bot/app identity from the authorizing user (currently
+	// only Slack). Empty for providers without that distinction.
+	BotAccessToken string
+
+	// IDToken is the OIDC id_token returned alongside AccessToken, for
+	// providers that support OIDC and were granted the "openid" scope.
+	// Empty for providers that don't return one (see OIDCUserInfoProvider).
+	IDToken string
 }
 
 // UserInfo represents the user information retrieved from an OAuth provider.
@@ -47,6 +155,11 @@ type UserInfo struct {
 
 	// Picture is the URL to the user's profile picture.
 	Picture string
+
+	// WorkspaceID is the provider's workspace/team identifier, for providers
+	// that have one (currently only Slack, populated from team.id). Empty
+	// otherwise.
+	WorkspaceID string
 }
 
 // ProviderType represents the type of OAuth provider.
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RefreshTokenStatus is the state RefreshTokenStore.Consume finds a jti in
+// just before marking it consumed.
+type RefreshTokenStatus int
+
+const (
+	// RefreshTokenUnknown means the store has no record of jti at all (e.g.
+	// it was never registered, or has already expired and been forgotten).
+	RefreshTokenUnknown RefreshTokenStatus = iota
+	// RefreshTokenActive means jti was registered and hasn't been consumed
+	// or revoked yet: the expected state for a legitimate refresh.
+	RefreshTokenActive
+	// RefreshTokenConsumed means jti was already exchanged for a new token
+	// pair once before, so presenting it again means it was stolen and
+	// replayed.
+	RefreshTokenConsumed
+	// RefreshTokenFamilyRevoked means jti's family was explicitly revoked
+	// (via RevokeFamily or RevokeUser), e.g. by an earlier theft detection
+	// or a logout-all-devices request.
+	RefreshTokenFamilyRevoked
+)
+
+// RefreshTokenStore tracks refresh tokens by jti, grouped into families, so
+// JWTService.RefreshTokens can detect a stolen refresh token being replayed
+// after its legitimate holder already rotated it. Every refresh token
+// minted from the same login (see JWTService.GenerateTokens) shares a
+// family ID; rotating consumes the presented jti and registers the newly
+// issued one under that same family.
+type RefreshTokenStore interface {
+	// Register records jti as active, belonging to familyID and userID, for
+	// ttl (the refresh token's remaining lifetime).
+	Register(ctx context.Context, userID, familyID, jti string, ttl time.Duration) error
+
+	// Consume marks jti as consumed and returns the status it had just
+	// before this call.
+	Consume(ctx context.Context, familyID, jti string) (RefreshTokenStatus, error)
+
+	// RevokeFamily revokes familyID: Consume reports
+	// RefreshTokenFamilyRevoked for every jti registered under it, now and
+	// in the future, until the revocation entry itself expires.
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// RevokeUser revokes every family ever registered for userID.
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+// refreshTokenRecord is a MemoryRefreshTokenStore entry.
+type refreshTokenRecord struct {
+	familyID  string
+	consumed  bool
+	expiresAt time.Time
+}
+
+// MemoryRefreshTokenStore is an in-memory RefreshTokenStore. Like
+// MemoryRevocationStore, it doesn't share state across instances, so it's
+// only suitable for single-instance deployments or as a fallback when no
+// distributed backend is configured.
+type MemoryRefreshTokenStore struct {
+	mu            sync.Mutex
+	tokens        map[string]*refreshTokenRecord // jti -> record
+	revokedFamily map[string]time.Time           // familyID -> revocation expiresAt
+	userFamilies  map[string]map[string]struct{} // userID -> familyIDs
+}
+
+// NewMemoryRefreshTokenStore creates a new in-memory RefreshTokenStore and
+// starts its cleanup loop.
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	s := &MemoryRefreshTokenStore{
+		tokens:        make(map[string]*refreshTokenRecord),
+		revokedFamily: make(map[string]time.Time),
+		userFamilies:  make(map[string]map[string]struct{}),
+	}
+	go s.cleanup()
+	return s
+}
+
+// Register implements RefreshTokenStore.
+func (s *MemoryRefreshTokenStore) Register(ctx context.Context, userID, familyID, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[jti] = &refreshTokenRecord{familyID: familyID, expiresAt: time.Now().Add(ttl)}
+
+	if s.userFamilies[userID] == nil {
+		s.userFamilies[userID] = make(map[string]struct{})
+	}
+	s.userFamilies[userID][familyID] = struct{}{}
+	return nil
+}
+
+// Consume implements RefreshTokenStore.
+func (s *MemoryRefreshTokenStore) Consume(ctx context.Context, familyID, jti string) (RefreshTokenStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if revokedAt, ok := s.revokedFamily[familyID]; ok {
+		if time.Now().After(revokedAt) {
+			delete(s.revokedFamily, familyID)
+		} else {
+			return RefreshTokenFamilyRevoked, nil
+		}
+	}
+
+	record, ok := s.tokens[jti]
+	if !ok {
+		return RefreshTokenUnknown, nil
+	}
+	if time.Now().After(record.expiresAt) {
+		delete(s.tokens, jti)
+		return RefreshTokenUnknown, nil
+	}
+	if record.consumed {
+		return RefreshTokenConsumed, nil
+	}
+	record.consumed = true
+	return RefreshTokenActive, nil
+}
+
+// RevokeFamily implements RefreshTokenStore.
+func (s *MemoryRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokedFamily[familyID] = time.Now().Add(refreshTokenExpiry)
+	return nil
+}
+
+// RevokeUser implements RefreshTokenStore.
+func (s *MemoryRefreshTokenStore) RevokeUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for familyID := range s.userFamilies[userID] {
+		s.revokedFamily[familyID] = time.Now().Add(refreshTokenExpiry)
+	}
+	return nil
+}
+
+// cleanup periodically removes expired entries to prevent memory leaks.
+func (s *MemoryRefreshTokenStore) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for jti, record := range s.tokens {
+			if now.After(record.expiresAt) {
+				delete(s.tokens, jti)
+			}
+		}
+		for familyID, expiresAt := range s.revokedFamily {
+			if now.After(expiresAt) {
+				delete(s.revokedFamily, familyID)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// RedisRefreshTokenStore is a RefreshTokenStore backed by Redis, so reuse
+// detection and family revocation are shared across every instance of the
+// service.
+type RedisRefreshTokenStore struct {
+	client *redis.Client
+}
+
+// NewRedisRefreshTokenStore creates a new Redis-backed RefreshTokenStore.
+func NewRedisRefreshTokenStore(client *redis.Client) *RedisRefreshTokenStore {
+	return &RedisRefreshTokenStore{client: client}
+}
+
+// Register implements RefreshTokenStore.
+func (s *RedisRefreshTokenStore) Register(ctx context.Context, userID, familyID, jti string, ttl time.Duration) error {
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, s.tokenKey(jti), "0", ttl) // "0" = not yet consumed
+	pipe.SAdd(ctx, s.userFamiliesKey(userID), familyID)
+	pipe.Expire(ctx, s.userFamiliesKey(userID), refreshTokenExpiry)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis register refresh token: %w", err)
+	}
+	return nil
+}
+
+// Consume implements RefreshTokenStore.
+func (s *RedisRefreshTokenStore) Consume(ctx context.Context, familyID, jti string) (RefreshTokenStatus, error) {
+	revoked, err := s.client.Exists(ctx, s.familyRevokedKey(familyID)).Result()
+	if err != nil {
+		return RefreshTokenUnknown, fmt.Errorf("redis exists: %w", err)
+	}
+	if revoked > 0 {
+		return RefreshTokenFamilyRevoked, nil
+	}
+
+	// SET ... GET KEEPTTL atomically swaps in "1" (consumed) and reports the
+	// previous value, without clearing the key's remaining TTL.
+	prev, err := s.client.SetArgs(ctx, s.tokenKey(jti), "1", redis.SetArgs{
+		Get:     true,
+		KeepTTL: true,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return RefreshTokenUnknown, nil
+		}
+		return RefreshTokenUnknown, fmt.Errorf("redis set: %w", err)
+	}
+	if prev == "1" {
+		return RefreshTokenConsumed, nil
+	}
+	return RefreshTokenActive, nil
+}
+
+// RevokeFamily implements RefreshTokenStore.
+func (s *RedisRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := s.client.Set(ctx, s.familyRevokedKey(familyID), "1", refreshTokenExpiry).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser implements RefreshTokenStore.
+func (s *RedisRefreshTokenStore) RevokeUser(ctx context.Context, userID string) error {
+	familyIDs, err := s.client.SMembers(ctx, s.userFamiliesKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("redis smembers: %w", err)
+	}
+	for _, familyID := range familyIDs {
+		if err := s.RevokeFamily(ctx, familyID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RedisRefreshTokenStore) tokenKey(jti string) string {
+	return "refresh_token:" + jti
+}
+
+func (s *RedisRefreshTokenStore) familyRevokedKey(familyID string) string {
+	return "refresh_family_revoked:" + familyID
+}
+
+func (s *RedisRefreshTokenStore) userFamiliesKey(userID string) string {
+	return "refresh_user_families:" + userID
+}
+
+// Ensure MemoryRefreshTokenStore and RedisRefreshTokenStore implement
+// RefreshTokenStore.
+var _ RefreshTokenStore = (*MemoryRefreshTokenStore)(nil)
+var _ RefreshTokenStore = (*RedisRefreshTokenStore)(nil)
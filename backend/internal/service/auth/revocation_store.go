@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenRevocationStore records revoked JWT IDs (jti) so a logged-out or
+// compromised token can be rejected before it naturally expires. Entries
+// are kept only for the token's remaining lifetime (see JWTService.
+// RevokeToken), so the store never grows to hold more than
+// currently-valid-but-revoked tokens.
+type TokenRevocationStore interface {
+	// Revoke marks jti as revoked for ttl, after which it's safe to forget
+	// it (the token it belonged to will have expired naturally by then).
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+
+	// IsRevoked reports whether jti has been revoked.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// MemoryRevocationStore is an in-memory TokenRevocationStore. Like
+// middleware.MemoryStore for rate limiting, it doesn't share state across
+// instances, so it's only suitable for single-instance deployments or as a
+// fallback when no distributed backend is configured.
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time // jti -> expiresAt
+}
+
+// NewMemoryRevocationStore creates a new in-memory TokenRevocationStore and
+// starts its cleanup loop.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	s := &MemoryRevocationStore{entries: make(map[string]time.Time)}
+	go s.cleanup()
+	return s
+}
+
+// Revoke implements TokenRevocationStore.
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[jti] = time.Now().Add(ttl)
+	return nil
+}
+
+// IsRevoked implements TokenRevocationStore.
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.entries[jti]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(expiresAt) {
+		delete(s.entries, jti)
+		return false, nil
+	}
+	return true, nil
+}
+
+// cleanup periodically removes expired entries to prevent memory leaks.
+func (s *MemoryRevocationStore) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		now := time.Now()
+		for jti, expiresAt := range s.entries {
+			if now.After(expiresAt) {
+				delete(s.entries, jti)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// RedisRevocationStore is a TokenRevocationStore backed by Redis, using a
+// SET with expiry per jti so revocations are shared across every instance
+// of the service.
+type RedisRevocationStore struct {
+	client *redis.Client
+}
+
+// NewRedisRevocationStore creates a new Redis-backed TokenRevocationStore.
+func NewRedisRevocationStore(client *redis.Client) *RedisRevocationStore {
+	return &RedisRevocationStore{client: client}
+}
+
+// Revoke implements TokenRevocationStore.
+func (s *RedisRevocationStore) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.key(jti), "1", ttl).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked implements TokenRevocationStore.
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.key(jti)).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis exists: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *RedisRevocationStore) key(jti string) string {
+	return "revoked_token:" + jti
+}
+
+// Ensure MemoryRevocationStore and RedisRevocationStore implement
+// TokenRevocationStore.
+var _ TokenRevocationStore = (*MemoryRevocationStore)(nil)
+var _ TokenRevocationStore = (*RedisRevocationStore)(nil)
@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK is a single entry of a JSON Web Key Set (RFC 7517), describing the
+// public half of a signing key. HMAC keys have no public half and are
+// omitted from a JWKS document entirely (see SigningKey.JWK).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSDocument is the standard response body of a JWKS endpoint.
+type JWKSDocument struct {
+	Keys []JWK `json:"keys"`
+}
+
+// SigningKey is a single key JWTService can sign or verify tokens with,
+// identified by a "kid" so a KeySet can hold several at once during a
+// rotation window (see KeySet).
+type SigningKey interface {
+	// Kid identifies this key in a signed token's "kid" header and, for keys
+	// with a public half, in the JWKS document JWKSHandler serves.
+	Kid() string
+	// Method is the signing method used to both sign and verify with this key.
+	Method() jwt.SigningMethod
+	// SignKey returns the key jwt.Token.SignedString needs: an HMAC secret
+	// for HMAC methods, or an RSA/ECDSA private key for asymmetric ones.
+	SignKey() interface{}
+	// VerifyKey returns the key a keyfunc needs to verify a token signed with
+	// this key: the same HMAC secret, or the RSA/ECDSA public key.
+	VerifyKey() interface{}
+	// JWK returns this key's public half as a JWKS entry. ok is false for
+	// HMAC keys, which have no public half to publish.
+	JWK() (key JWK, ok bool)
+}
+
+// hmacSigningKey is a SigningKey backed by a shared secret.
+type hmacSigningKey struct {
+	kid    string
+	method jwt.SigningMethod
+	secret []byte
+}
+
+func (k *hmacSigningKey) Kid() string { return k.kid }
+func (k *hmacSigningKey) Method() jwt.SigningMethod { return k.method }
+func (k *hmacSigningKey) SignKey() interface{} { return k.secret }
+func (k *hmacSigningKey) VerifyKey() interface{} { return k.secret }
+func (k *hmacSigningKey) JWK() (JWK, bool) { return JWK{}, false }
+
+// rsaSigningKey is a SigningKey backed by an RSA private key.
+type rsaSigningKey struct {
+	kid    string
+	method jwt.SigningMethod
+	priv   *rsa.PrivateKey
+}
+
+func (k *rsaSigningKey) Kid() string { return k.kid }
+func (k *rsaSigningKey) Method() jwt.SigningMethod { return k.method }
+func (k *rsaSigningKey) SignKey() interface{} { return k.priv }
+func (k *rsaSigningKey) VerifyKey() interface{} { return &k.priv.PublicKey }
+
+func (k *rsaSigningKey) JWK() (JWK, bool) {
+	pub := k.priv.PublicKey
+	return JWK{
+		Kty: "RSA",
+		Kid: k.kid,
+		Use: "sig",
+		Alg: k.method.Alg(),
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}, true
+}
+
+// ecdsaSigningKey is a SigningKey backed by an ECDSA private key.
+type ecdsaSigningKey struct {
+	kid    string
+	method jwt.SigningMethod
+	priv   *ecdsa.PrivateKey
+}
+
+func (k *ecdsaSigningKey) Kid() string { return k.kid }
+func (k *ecdsaSigningKey) Method() jwt.SigningMethod { return k.method }
+func (k *ecdsaSigningKey) SignKey() interface{} { return k.priv }
+func (k *ecdsaSigningKey) VerifyKey() interface{} { return &k.priv.PublicKey }
+
+func (k *ecdsaSigningKey) JWK() (JWK, bool) {
+	pub := k.priv.PublicKey
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	return JWK{
+		Kty: "EC",
+		Kid: k.kid,
+		Use: "sig",
+		Alg: k.method.Alg(),
+		Crv: pub.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+	}, true
+}
+
+// LoadSigningKey builds a SigningKey for alg, one of the algorithm names
+// jwt.GetSigningMethod recognizes ("HS256", "RS256", "ES256", ...). For HMAC
+// algorithms, secretOrPath is used directly as the HMAC secret. For RSA and
+// ECDSA algorithms, secretOrPath is a filesystem path to a PEM-encoded
+// PKCS#1/PKCS#8/EC private key. kid identifies the key in a token's "kid"
+// header and the JWKS document; if empty, it's derived from a fingerprint of
+// the key material so callers don't have to invent one.
+func LoadSigningKey(alg, kid, secretOrPath string) (SigningKey, error) {
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, fmt.Errorf("auth: unsupported JWT signing algorithm %q", alg)
+	}
+
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if kid == "" {
+			kid = fingerprint([]byte(secretOrPath))
+		}
+		return &hmacSigningKey{kid: kid, method: method, secret: []byte(secretOrPath)}, nil
+
+	case *jwt.SigningMethodRSA:
+		priv, err := parseRSAPrivateKeyPEMFile(secretOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("load RSA signing key %s: %w", secretOrPath, err)
+		}
+		if kid == "" {
+			kid = fingerprint(x509.MarshalPKCS1PublicKey(&priv.PublicKey))
+		}
+		return &rsaSigningKey{kid: kid, method: method, priv: priv}, nil
+
+	case *jwt.SigningMethodECDSA:
+		priv, err := parseECDSAPrivateKeyPEMFile(secretOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("load ECDSA signing key %s: %w", secretOrPath, err)
+		}
+		if kid == "" {
+			pubBytes, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("marshal ECDSA public key: %w", err)
+			}
+			kid = fingerprint(pubBytes)
+		}
+		return &ecdsaSigningKey{kid: kid, method: method, priv: priv}, nil
+
+	default:
+		return nil, fmt.Errorf("auth: unsupported JWT signing algorithm %q", alg)
+	}
+}
+
+// fingerprint derives a short, stable kid from key material, so operators
+// don't have to assign one explicitly for every key in JWT_PRIVATE_KEY_PATH
+// and JWT_PREVIOUS_KEYS.
+func fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:16]
+}
+
+// parseRSAPrivateKeyPEMFile reads and parses a PEM-encoded RSA private key,
+// accepting both PKCS#1 ("RSA PRIVATE KEY") and PKCS#8 ("PRIVATE KEY") forms.
+func parseRSAPrivateKeyPEMFile(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if priv, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return priv, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("PEM file does not contain an RSA private key")
+	}
+	return priv, nil
+}
+
+// parseECDSAPrivateKeyPEMFile reads and parses a PEM-encoded ECDSA private
+// key, accepting both SEC1 ("EC PRIVATE KEY") and PKCS#8 ("PRIVATE KEY") forms.
+func parseECDSAPrivateKeyPEMFile(path string) (*ecdsa.PrivateKey, error) {
+	block, err := readPEMFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if priv, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return priv, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("PEM file does not contain an ECDSA private key")
+	}
+	return priv, nil
+}
+
+// readPEMFile reads path and decodes its first PEM block.
+func readPEMFile(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	return block, nil
+}
+
+// KeySet holds the signing keys a JWTService uses: exactly one primary key,
+// used to sign newly issued tokens, plus zero or more previous keys kept
+// around only so tokens signed before a rotation still validate until they
+// naturally expire.
+type KeySet struct {
+	primary SigningKey
+	byKid   map[string]SigningKey
+}
+
+// NewKeySet builds a KeySet with primary as the signing key and previous as
+// additional keys accepted for verification only.
+func NewKeySet(primary SigningKey, previous ...SigningKey) *KeySet {
+	byKid := make(map[string]SigningKey, len(previous)+1)
+	byKid[primary.Kid()] = primary
+	for _, k := range previous {
+		byKid[k.Kid()] = k
+	}
+	return &KeySet{primary: primary, byKid: byKid}
+}
+
+// Primary returns the key new tokens are signed with.
+func (ks *KeySet) Primary() SigningKey {
+	return ks.primary
+}
+
+// Lookup returns the key identified by kid, for verifying a token signed
+// with it, whether it's the current primary key or a previous one kept
+// around for a rotation window.
+func (ks *KeySet) Lookup(kid string) (SigningKey, bool) {
+	k, ok := ks.byKid[kid]
+	return k, ok
+}
+
+// JWKS returns the public JWKS document for every key in the set that has a
+// public half to publish (HMAC keys are skipped), with the primary key first.
+func (ks *KeySet) JWKS() JWKSDocument {
+	doc := JWKSDocument{Keys: make([]JWK, 0, len(ks.byKid))}
+	if jwk, ok := ks.primary.JWK(); ok {
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	for kid, k := range ks.byKid {
+		if kid == ks.primary.Kid() {
+			continue
+		}
+		if jwk, ok := k.JWK(); ok {
+			doc.Keys = append(doc.Keys, jwk)
+		}
+	}
+	return doc
+}
+
+// Ensure hmacSigningKey, rsaSigningKey, and ecdsaSigningKey implement SigningKey.
+var (
+	_ SigningKey = (*hmacSigningKey)(nil)
+	_ SigningKey = (*rsaSigningKey)(nil)
+	_ SigningKey = (*ecdsaSigningKey)(nil)
+)
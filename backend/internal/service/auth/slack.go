@@ -63,7 +63,13 @@ type slackUserIdentityResponse struct {
 	} `json:"team"`
 }
 
-// SlackProvider implements the OAuthProvider interface for Slack OAuth.
+// SlackProvider implements the OAuthProvider interface for Slack OAuth. It
+// intentionally authenticates via Slack's classic "Add to Slack" flow
+// (oauth.v2.access + users.identity) rather than Sign in with Slack's OpenID
+// Connect endpoints: workspace features added on top of this provider (the
+// signed Events API webhooks and file downloads in handler.SlackEventsHandler)
+// need the bot token and team ID that oauth.v2.access returns, which the
+// OIDC token/userInfo endpoints don't carry.
 type SlackProvider struct {
 	clientID     string
 	clientSecret string
@@ -72,14 +78,25 @@ type SlackProvider struct {
 	userScopes   []string
 }
 
-// NewSlackProvider creates a new Slack OAuth provider.
-func NewSlackProvider(clientID, clientSecret, redirectURL string) *SlackProvider {
+// DefaultSlackBotScopes are the bot scopes requested when NewSlackProvider is
+// given none explicitly: enough for Torifune to act as a workspace app
+// (download uploaded files, post OCR results back to a channel, and register
+// slash commands).
+var DefaultSlackBotScopes = []string{"files:read", "chat:write", "commands"}
+
+// NewSlackProvider creates a new Slack OAuth provider. botScopes are the
+// workspace-level (bot token) scopes to request in addition to the fixed
+// identity.* user scopes; pass nil to use DefaultSlackBotScopes.
+func NewSlackProvider(clientID, clientSecret, redirectURL string, botScopes []string) *SlackProvider {
+	if botScopes == nil {
+		botScopes = DefaultSlackBotScopes
+	}
 	return &SlackProvider{
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		redirectURL:  redirectURL,
 		// Bot scopes (for workspace-level permissions).
-		scopes: []string{},
+		scopes: botScopes,
 		// User scopes (for user-level permissions).
 		userScopes: []string{
 			"identity.basic",
@@ -89,8 +106,11 @@ func NewSlackProvider(clientID, clientSecret, redirectURL string) *SlackProvider
 	}
 }
 
-// GetAuthURL returns the Slack OAuth authorization URL.
-func (p *SlackProvider) GetAuthURL(state, codeChallenge string) string {
+// GetAuthURL returns the Slack OAuth authorization URL. nonce is accepted
+// for OAuthProvider conformance but unused: Slack's classic
+// oauth.v2.access flow doesn't return a verifiable id_token (see the doc
+// comment on SlackProvider).
+func (p *SlackProvider) GetAuthURL(state, codeChallenge, nonce string) string {
 	params := url.Values{}
 	params.Set("client_id", p.clientID)
 	params.Set("redirect_uri", p.redirectURL)
@@ -166,9 +186,64 @@ func (p *SlackProvider) ExchangeCode(ctx context.Context, code, codeVerifier str
 	}
 
 	return &OAuthToken{
-		AccessToken:  accessToken,
-		RefreshToken: tokenResp.RefreshToken,
-		ExpiresAt:    expiresAt,
+		AccessToken:    accessToken,
+		RefreshToken:   tokenResp.RefreshToken,
+		ExpiresAt:      expiresAt,
+		BotAccessToken: tokenResp.AccessToken,
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for new Slack tokens via
+// oauth.v2.access's grant_type=refresh_token mode (Slack's token rotation).
+func (p *SlackProvider) RefreshToken(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	data := url.Values{}
+	data.Set("client_id", p.clientID)
+	data.Set("client_secret", p.clientSecret)
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", refreshToken)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, slackTokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp slackTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	if !tokenResp.OK {
+		return nil, fmt.Errorf("slack API error: %s", tokenResp.Error)
+	}
+
+	accessToken := tokenResp.AuthedUser.AccessToken
+	if accessToken == "" {
+		accessToken = tokenResp.AccessToken
+	}
+
+	expiresAt := time.Time{}
+	if tokenResp.ExpiresIn > 0 {
+		expiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+
+	return &OAuthToken{
+		AccessToken:    accessToken,
+		RefreshToken:   tokenResp.RefreshToken,
+		ExpiresAt:      expiresAt,
+		BotAccessToken: tokenResp.AccessToken,
 	}, nil
 }
 
@@ -212,12 +287,14 @@ func (p *SlackProvider) GetUserInfo(ctx context.Context, accessToken string) (*U
 	}
 
 	return &UserInfo{
-		ID:      userResp.User.ID,
-		Email:   userResp.User.Email,
-		Name:    userResp.User.Name,
-		Picture: picture,
+		ID:          userResp.User.ID,
+		Email:       userResp.User.Email,
+		Name:        userResp.User.Name,
+		Picture:     picture,
+		WorkspaceID: userResp.Team.ID,
 	}, nil
 }
 
-// Ensure SlackProvider implements OAuthProvider.
+// Ensure SlackProvider implements OAuthProvider and TokenRefreshingProvider.
 var _ OAuthProvider = (*SlackProvider)(nil)
+var _ TokenRefreshingProvider = (*SlackProvider)(nil)
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// authStateExpiry bounds how long a Login-issued state cookie is valid:
+// long enough for a user to complete a provider's consent screen, short
+// enough that a stolen cookie isn't useful for long.
+const authStateExpiry = 10 * time.Minute
+
+// AuthState is the per-login-attempt state AuthHandler.Login hands back to
+// the browser as a signed cookie, so AuthHandler.CallbackRedirect can
+// resume the flow (verify state, use the PKCE verifier) without
+// server-side session storage.
+type AuthState struct {
+	// Provider is checked against the callback's {provider} path param, so
+	// a cookie from one provider's login attempt can't complete another's.
+	Provider string `json:"provider"`
+	// State is compared against the callback's "state" query parameter.
+	State string `json:"state"`
+	// CodeVerifier is the PKCE verifier generated for this attempt.
+	CodeVerifier string `json:"code_verifier"`
+	// Nonce is compared against the "nonce" claim of an id_token returned by
+	// the provider's token exchange, so a replayed id_token from a prior
+	// login attempt can't complete this one (see OIDCUserInfoProvider).
+	Nonce string `json:"nonce"`
+	// RedirectAfter is where to send the browser once login completes. If
+	// empty, callers fall back to their own default (e.g. FrontendURL).
+	RedirectAfter string `json:"redirect_after,omitempty"`
+}
+
+// authStateClaims embeds AuthState in a JWT so it can be signed and
+// verified with the same machinery as login tokens.
+type authStateClaims struct {
+	jwt.RegisteredClaims
+	AuthState
+}
+
+// ErrInvalidAuthState is returned by AuthStateCodec.Decode for a cookie
+// that's missing, expired, or doesn't verify against its secret key.
+var ErrInvalidAuthState = errors.New("invalid auth state")
+
+// AuthStateCodec signs and verifies AuthState as a short-lived JWT, so it
+// can round-trip through a plain HttpOnly cookie instead of needing a
+// server-side store.
+type AuthStateCodec struct {
+	secretKey []byte
+}
+
+// NewAuthStateCodec creates a new AuthStateCodec.
+func NewAuthStateCodec(secretKey string) *AuthStateCodec {
+	return &AuthStateCodec{secretKey: []byte(secretKey)}
+}
+
+// Encode signs s and returns it as a compact JWT suitable for a cookie value.
+func (c *AuthStateCodec) Encode(s AuthState) (string, error) {
+	now := time.Now()
+	claims := authStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(authStateExpiry)),
+		},
+		AuthState: s,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(c.secretKey)
+}
+
+// Decode verifies tokenString and returns the AuthState it carries.
+func (c *AuthStateCodec) Decode(tokenString string) (*AuthState, error) {
+	claims := &authStateClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return c.secretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidAuthState
+	}
+
+	return &claims.AuthState, nil
+}
+
+// GetAuthStateExpiry returns how long a signed AuthState cookie stays valid.
+func GetAuthStateExpiry() time.Duration {
+	return authStateExpiry
+}
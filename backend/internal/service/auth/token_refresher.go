@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultRefreshSkew is how far ahead of actual expiry a token is considered
+// due for refresh, when TokenRefresher is constructed with skew <= 0.
+const defaultRefreshSkew = 5 * time.Minute
+
+// TokenRefresher transparently refreshes stored OAuth tokens that are close
+// to expiring, so callers (handlers, middleware) never have to handle a
+// stale access token themselves.
+type TokenRefresher struct {
+	store     TokenStore
+	providers map[ProviderType]OAuthProvider
+	skew      time.Duration
+}
+
+// NewTokenRefresher creates a TokenRefresher backed by store, refreshing
+// through providers keyed by ProviderType. skew is how far ahead of
+// ExpiresAt a token is refreshed; pass 0 to use a 5 minute default.
+func NewTokenRefresher(store TokenStore, providers map[ProviderType]OAuthProvider, skew time.Duration) *TokenRefresher {
+	if skew <= 0 {
+		skew = defaultRefreshSkew
+	}
+	return &TokenRefresher{store: store, providers: providers, skew: skew}
+}
+
+// Get returns a valid OAuthToken for userID/provider, refreshing and
+// persisting a new one first if the stored token is within skew of expiring
+// (or already expired). If the provider doesn't support refreshing, or the
+// stored token has no refresh token, the stored token is returned as-is.
+func (tr *TokenRefresher) Get(ctx context.Context, userID string, provider ProviderType) (*OAuthToken, error) {
+	token, err := tr.store.Get(ctx, userID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.ExpiresAt.IsZero() || time.Until(token.ExpiresAt) > tr.skew {
+		return token, nil
+	}
+
+	refreshing, ok := tr.providers[provider].(TokenRefreshingProvider)
+	if !ok || token.RefreshToken == "" {
+		return token, nil
+	}
+
+	refreshed, err := refreshing.RefreshToken(ctx, token.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("auth: refresh token: %w", err)
+	}
+
+	// Some providers (e.g. Google, most of the time) don't rotate these on
+	// every refresh; carry the prior values forward so callers never see
+	// them disappear.
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	if refreshed.BotAccessToken == "" {
+		refreshed.BotAccessToken = token.BotAccessToken
+	}
+
+	if err := tr.store.Save(ctx, userID, provider, refreshed); err != nil {
+		return nil, fmt.Errorf("auth: persist refreshed token: %w", err)
+	}
+
+	return refreshed, nil
+}
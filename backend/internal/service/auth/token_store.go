@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	kms "cloud.google.com/go/kms/apiv1"
+	kmspb "cloud.google.com/go/kms/apiv1/kmspb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrTokenNotFound is returned when no stored token exists for a user/provider pair.
+var ErrTokenNotFound = errors.New("auth: token not found")
+
+// TokenStore persists an OAuthToken per user and provider, so it can be
+// retrieved and refreshed on a later request without the user reauthorizing.
+type TokenStore interface {
+	// Save encrypts and persists token for userID/provider, replacing any
+	// previously stored token.
+	Save(ctx context.Context, userID string, provider ProviderType, token *OAuthToken) error
+
+	// Get retrieves and decrypts the token stored for userID/provider.
+	// Returns ErrTokenNotFound if none exists.
+	Get(ctx context.Context, userID string, provider ProviderType) (*OAuthToken, error)
+}
+
+// tokenDoc is the Firestore document shape. AccessToken, RefreshToken and
+// BotAccessToken are stored as AES-256-GCM ciphertext under a per-document
+// data encryption key (DEK), itself wrapped by a KMS key-encryption key, so
+// a leaked Firestore export doesn't expose usable credentials.
+type tokenDoc struct {
+	WrappedDEK               string    `firestore:"wrappedDek"`
+	AccessTokenCiphertext    string    `firestore:"accessTokenCiphertext"`
+	RefreshTokenCiphertext   string    `firestore:"refreshTokenCiphertext,omitempty"`
+	BotAccessTokenCiphertext string    `firestore:"botAccessTokenCiphertext,omitempty"`
+	ExpiresAt                time.Time `firestore:"expiresAt"`
+	UpdatedAt                time.Time `firestore:"updatedAt"`
+}
+
+// FirestoreTokenStore implements TokenStore using Firestore for storage and
+// Cloud KMS envelope encryption for confidentiality at rest.
+type FirestoreTokenStore struct {
+	client     *firestore.Client
+	collection string
+	kms        *kms.KeyManagementClient
+	kmsKeyName string
+}
+
+// NewFirestoreTokenStore creates a new FirestoreTokenStore. kmsKeyName is the
+// full resource name of the KMS key used to wrap each document's data
+// encryption key, e.g.
+// "projects/p/locations/l/keyRings/r/cryptoKeys/oauth-tokens".
+func NewFirestoreTokenStore(client *firestore.Client, kmsClient *kms.KeyManagementClient, kmsKeyName string) *FirestoreTokenStore {
+	return &FirestoreTokenStore{
+		client:     client,
+		collection: "oauth_tokens",
+		kms:        kmsClient,
+		kmsKeyName: kmsKeyName,
+	}
+}
+
+// docID returns the Firestore document ID for a user/provider pair.
+func (s *FirestoreTokenStore) docID(userID string, provider ProviderType) string {
+	return userID + "_" + string(provider)
+}
+
+// Save encrypts and persists token for userID/provider.
+func (s *FirestoreTokenStore) Save(ctx context.Context, userID string, provider ProviderType, token *OAuthToken) error {
+	dek, wrappedDEK, err := s.newWrappedDEK(ctx)
+	if err != nil {
+		return fmt.Errorf("auth: generate data encryption key: %w", err)
+	}
+
+	accessCiphertext, err := encryptWithDEK(dek, token.AccessToken)
+	if err != nil {
+		return fmt.Errorf("auth: encrypt access token: %w", err)
+	}
+	refreshCiphertext, err := encryptWithDEK(dek, token.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("auth: encrypt refresh token: %w", err)
+	}
+	botCiphertext, err := encryptWithDEK(dek, token.BotAccessToken)
+	if err != nil {
+		return fmt.Errorf("auth: encrypt bot access token: %w", err)
+	}
+
+	doc := tokenDoc{
+		WrappedDEK:               wrappedDEK,
+		AccessTokenCiphertext:    accessCiphertext,
+		RefreshTokenCiphertext:   refreshCiphertext,
+		BotAccessTokenCiphertext: botCiphertext,
+		ExpiresAt:                token.ExpiresAt,
+		UpdatedAt:                time.Now(),
+	}
+
+	_, err = s.client.Collection(s.collection).Doc(s.docID(userID, provider)).Set(ctx, doc)
+	return err
+}
+
+// Get retrieves and decrypts the token stored for userID/provider.
+func (s *FirestoreTokenStore) Get(ctx context.Context, userID string, provider ProviderType) (*OAuthToken, error) {
+	doc, err := s.client.Collection(s.collection).Doc(s.docID(userID, provider)).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	var stored tokenDoc
+	if err := doc.DataTo(&stored); err != nil {
+		return nil, err
+	}
+
+	dek, err := s.unwrapDEK(ctx, stored.WrappedDEK)
+	if err != nil {
+		return nil, fmt.Errorf("auth: unwrap data encryption key: %w", err)
+	}
+
+	accessToken, err := decryptWithDEK(dek, stored.AccessTokenCiphertext)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decrypt access token: %w", err)
+	}
+	refreshToken, err := decryptWithDEK(dek, stored.RefreshTokenCiphertext)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decrypt refresh token: %w", err)
+	}
+	botAccessToken, err := decryptWithDEK(dek, stored.BotAccessTokenCiphertext)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decrypt bot access token: %w", err)
+	}
+
+	return &OAuthToken{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		ExpiresAt:      stored.ExpiresAt,
+		BotAccessToken: botAccessToken,
+	}, nil
+}
+
+// newWrappedDEK generates a random 32-byte data encryption key and wraps it
+// with the KMS key-encryption key. The raw DEK is returned for immediate use
+// encrypting this document and is never itself persisted; only wrapped is.
+func (s *FirestoreTokenStore) newWrappedDEK(ctx context.Context) (dek []byte, wrapped string, err error) {
+	dek = make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, "", err
+	}
+
+	resp, err := s.kms.Encrypt(ctx, &kmspb.EncryptRequest{
+		Name:      s.kmsKeyName,
+		Plaintext: dek,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return dek, base64.StdEncoding.EncodeToString(resp.Ciphertext), nil
+}
+
+// unwrapDEK asks KMS to decrypt a data encryption key previously wrapped by newWrappedDEK.
+func (s *FirestoreTokenStore) unwrapDEK(ctx context.Context, wrapped string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.kms.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:       s.kmsKeyName,
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Plaintext, nil
+}
+
+// encryptWithDEK seals plaintext with AES-256-GCM under dek, returning a
+// base64-encoded nonce||ciphertext. Empty input encrypts to an empty string
+// so optional fields (RefreshToken, BotAccessToken) round-trip as empty.
+func encryptWithDEK(dek []byte, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptWithDEK reverses encryptWithDEK. Empty input decrypts to an empty string.
+func decryptWithDEK(dek []byte, encoded string) (string, error) {
+	if encoded == "" {
+		return "", nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("auth: ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Ensure FirestoreTokenStore implements TokenStore.
+var _ TokenStore = (*FirestoreTokenStore)(nil)
@@ -0,0 +1,264 @@
+// Package usage defines per-tier quota policy: which limits apply to an
+// entity's OCR usage, and what happens once a paid tier's included monthly
+// allowance is exceeded (a Stripe metered-billing usage record instead of
+// an outright rejection).
+package usage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stellar/torifune/backend/internal/entitlements"
+	"github.com/stellar/torifune/backend/internal/logging"
+	"github.com/stellar/torifune/backend/internal/ocr"
+	"github.com/stellar/torifune/backend/internal/repository"
+)
+
+// Tier is a named quota policy.
+type Tier struct {
+	Name string
+	// MonthlyOrgLimit is the included monthly allowance for an organization
+	// (or a standalone user with no organization) before overage applies.
+	// Zero means unlimited.
+	MonthlyOrgLimit int
+	// DailyUserLimit caps a single user's usage within a day, independent of
+	// their organization's monthly allowance, so one heavy user can't
+	// exhaust a team's whole month in a day. Zero means no per-user cap.
+	DailyUserLimit int
+	// MaxConcurrentJobs caps the number of async batch jobs an entity may
+	// have queued or processing at once. Zero means no cap.
+	MaxConcurrentJobs int
+	// OveragePriceCents is the per-unit overage price, in cents. PolicyEngine
+	// only checks whether it is set: usage past MonthlyOrgLimit is then
+	// reported to Stripe as a unit count, and the price itself is not sent.
+	// Zero means overage isn't billed; requests are rejected instead once
+	// MonthlyOrgLimit is hit.
+	OveragePriceCents int
+}
+
+// DefaultTiers are the built-in tier definitions. Enterprise customers get
+// their MonthlyOrgLimit from entitlements.Grant.CustomQuota instead of a
+// fixed default, since enterprise limits are negotiated per contract.
+var DefaultTiers = map[string]Tier{
+	"free": {
+		Name:            "free",
+		MonthlyOrgLimit: 100,
+		DailyUserLimit:  20,
+	},
+	"pro": {
+		Name:              "pro",
+		MonthlyOrgLimit:   10000,
+		DailyUserLimit:    2000,
+		MaxConcurrentJobs: 5,
+		OveragePriceCents: 2,
+	},
+	"enterprise": {
+		Name:              "enterprise",
+		MaxConcurrentJobs: 20,
+		OveragePriceCents: 2,
+	},
+}
+
+// BillingReporter records billable overage units against a metered Stripe
+// subscription item.
+type BillingReporter interface {
+	ReportUsage(ctx context.Context, subscriptionItemID string, quantity int) error
+}
+
+// Decision is the outcome of evaluating a request against a PolicyEngine.
+type Decision struct {
+	Allowed bool
+	// Reason is set when Allowed is false: "daily_user_limit",
+	// "monthly_org_limit", or "concurrent_job_limit".
+	Reason string
+	// Overage is the number of units billed to Stripe because they exceeded
+	// MonthlyOrgLimit on a tier that allows overage instead of rejecting.
+	Overage int
+	// Remaining is set alongside a "monthly_org_limit" Reason: how many
+	// units remain in the current month. Zero otherwise.
+	Remaining int
+}
+
+// PolicyEngine evaluates OCR requests against a tier's combined limits: per-
+// user daily, per-org monthly, and per-org concurrent async jobs. Tiers with
+// OveragePriceCents set bill usage past the monthly limit to Stripe instead
+// of rejecting it.
+type PolicyEngine struct {
+	usageRepo    repository.UsageRepository
+	jobStore     ocr.Store
+	entitlements *entitlements.Service
+	billing      BillingReporter
+	tiers        map[string]Tier
+}
+
+// NewPolicyEngine creates a PolicyEngine. entitlementsSvc and billing may be
+// nil; without entitlementsSvc every entity evaluates against the "free"
+// tier, and without billing, overage is tracked in usage history but never
+// reported to Stripe.
+func NewPolicyEngine(usageRepo repository.UsageRepository, jobStore ocr.Store, entitlementsSvc *entitlements.Service, billing BillingReporter) *PolicyEngine {
+	return &PolicyEngine{
+		usageRepo:    usageRepo,
+		jobStore:     jobStore,
+		entitlements: entitlementsSvc,
+		billing:      billing,
+		tiers:        DefaultTiers,
+	}
+}
+
+// tierFor resolves the Tier that applies to entityID, consulting
+// entitlements for the tier name (via "tier_pro"/"tier_enterprise" feature
+// flags) and, for enterprise, a custom monthly limit.
+func (e *PolicyEngine) tierFor(entityID string) Tier {
+	if e.entitlements == nil {
+		return e.tiers["free"]
+	}
+
+	grant, ok := e.entitlements.Get(entityID)
+	if !ok {
+		return e.tiers["free"]
+	}
+
+	switch {
+	case grant.HasFeature("tier_enterprise"):
+		tier := e.tiers["enterprise"]
+		if grant.CustomQuota > 0 {
+			tier.MonthlyOrgLimit = grant.CustomQuota
+		}
+		return tier
+	case grant.HasFeature("tier_pro"):
+		return e.tiers["pro"]
+	default:
+		return e.tiers["free"]
+	}
+}
+
+// Evaluate checks a request to process count documents for userID within
+// entityID/entityType against entityID's tier, reserving daily and monthly
+// quota as it goes. If the returned Decision is not Allowed, nothing was
+// reserved. If it is Allowed, call Release with the same arguments should
+// the request ultimately not be carried out (e.g. processing fails after
+// being approved), to roll the reservation back.
+func (e *PolicyEngine) Evaluate(ctx context.Context, entityID, entityType, userID string, count int) (Decision, error) {
+	tier := e.tierFor(entityID)
+
+	if tier.MaxConcurrentJobs > 0 && e.jobStore != nil {
+		active, err := e.jobStore.CountActive(ctx, entityID)
+		if err != nil {
+			return Decision{}, fmt.Errorf("usage: count active jobs: %w", err)
+		}
+		if active >= tier.MaxConcurrentJobs {
+			return Decision{Reason: "concurrent_job_limit"}, nil
+		}
+	}
+
+	if tier.DailyUserLimit > 0 {
+		ok, _, err := e.usageRepo.ReserveDailyQuota(ctx, userID, count, tier.DailyUserLimit)
+		if err != nil {
+			return Decision{}, fmt.Errorf("usage: reserve daily quota: %w", err)
+		}
+		if !ok {
+			return Decision{Reason: "daily_user_limit"}, nil
+		}
+	}
+
+	decision, err := e.reserveMonthly(ctx, entityID, entityType, tier, count)
+	if err != nil {
+		if tier.DailyUserLimit > 0 {
+			e.releaseDaily(ctx, userID, count)
+		}
+		return Decision{}, err
+	}
+	if !decision.Allowed && tier.DailyUserLimit > 0 {
+		e.releaseDaily(ctx, userID, count)
+	}
+
+	return decision, nil
+}
+
+// reserveMonthly reserves count units of entityID's monthly allowance. Units
+// past tier.MonthlyOrgLimit are still reserved (never rejected) when the
+// tier allows overage billing, and reported to Stripe.
+func (e *PolicyEngine) reserveMonthly(ctx context.Context, entityID, entityType string, tier Tier, count int) (Decision, error) {
+	if tier.MonthlyOrgLimit <= 0 {
+		// Unlimited: still record usage for history, never rejects.
+		if err := e.usageRepo.IncrementCount(ctx, entityID, entityType, count); err != nil {
+			return Decision{}, fmt.Errorf("usage: record usage: %w", err)
+		}
+		return Decision{Allowed: true}, nil
+	}
+
+	if tier.OveragePriceCents <= 0 {
+		ok, remaining, err := e.usageRepo.ReserveQuota(ctx, entityID, entityType, count, tier.MonthlyOrgLimit, false)
+		if err != nil {
+			return Decision{}, fmt.Errorf("usage: reserve monthly quota: %w", err)
+		}
+		if !ok {
+			return Decision{Reason: "monthly_org_limit", Remaining: remaining}, nil
+		}
+		return Decision{Allowed: true}, nil
+	}
+
+	current, err := e.usageRepo.GetCurrentMonth(ctx, entityID, entityType)
+	if err != nil {
+		return Decision{}, fmt.Errorf("usage: get current month: %w", err)
+	}
+
+	remaining := tier.MonthlyOrgLimit - current.ProcessedCount
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	overage := 0
+	if count > remaining {
+		overage = count - remaining
+	}
+
+	// unlimited=true: overage tiers never reject, they bill instead.
+	if _, _, err := e.usageRepo.ReserveQuota(ctx, entityID, entityType, count, tier.MonthlyOrgLimit, true); err != nil {
+		return Decision{}, fmt.Errorf("usage: record usage: %w", err)
+	}
+
+	if overage > 0 {
+		e.billOverage(ctx, entityID, overage)
+	}
+
+	return Decision{Allowed: true, Overage: overage}, nil
+}
+
+// billOverage reports overage units to Stripe against entityID's grant, if
+// one is configured for metered billing. Failures are logged rather than
+// surfaced, since by this point the request has already been approved.
+func (e *PolicyEngine) billOverage(ctx context.Context, entityID string, units int) {
+	if e.billing == nil || e.entitlements == nil {
+		return
+	}
+
+	grant, ok := e.entitlements.Get(entityID)
+	if !ok || grant.StripeSubscriptionItemID == "" {
+		return
+	}
+
+	if err := e.billing.ReportUsage(ctx, grant.StripeSubscriptionItemID, units); err != nil {
+		logging.FromContext(ctx).Error("usage: failed to report overage to stripe",
+			"entityId", entityID, "units", units, "error", err)
+	}
+}
+
+// releaseDaily rolls back a daily reservation, logging rather than failing
+// if the rollback itself errors.
+func (e *PolicyEngine) releaseDaily(ctx context.Context, userID string, count int) {
+	if err := e.usageRepo.ReleaseDailyQuota(ctx, userID, count); err != nil {
+		logging.FromContext(ctx).Error("usage: failed to release reserved daily quota", "userId", userID, "error", err)
+	}
+}
+
+// Release rolls back a reservation made by a prior, Allowed Evaluate call,
+// e.g. when processing fails after quota was reserved for it. It does not
+// reverse Stripe overage reporting; a brief over-report on a failed request
+// is preferred to under-billing actual usage.
+func (e *PolicyEngine) Release(ctx context.Context, entityID, entityType, userID string, count int) {
+	if err := e.usageRepo.ReleaseQuota(ctx, entityID, entityType, count); err != nil {
+		logging.FromContext(ctx).Error("usage: failed to release reserved monthly quota", "entityId", entityID, "error", err)
+	}
+	e.releaseDaily(ctx, userID, count)
+}